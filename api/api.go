@@ -17,11 +17,13 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/juju/errors"
 	"gopkg.in/macaroon-bakery.v2/httpbakery"
 
 	"github.com/juju/terms-client/api/wireformat"
+	"github.com/juju/terms-client/internal/httperror"
 )
 
 var defaultURL = "https://api.jujucharms.com/terms"
@@ -54,10 +56,18 @@ type Client interface {
 
 	// GetTermsByOwner implements the Client interface. It returns terms owned by the specified owner.
 	GetTermsByOwner(ctx context.Context, owner string) ([]wireformat.Term, error)
+
+	// ListTermRevisions returns all revisions of the term with the
+	// specified owner and name, ordered by revision number.
+	ListTermRevisions(ctx context.Context, owner, name string) ([]wireformat.Term, error)
+
+	// DebugStatus returns the results of the checks that form the
+	// status of the terms service.
+	DebugStatus(ctx context.Context) (*wireformat.DebugStatusResponse, error)
 }
 
 // headerName is the name of the header the handler will look for in incoming requests.
-const headerName = "X-Request-ID"
+const headerName = httperror.RequestIDHeader
 
 func requestWithId(ctx context.Context, req *http.Request) *http.Request {
 	id, ok := ctx.Value(headerName).(string)
@@ -106,29 +116,61 @@ func NewClient(options ...ClientOption) (Client, error) {
 type client struct {
 	serviceURL string
 	bclient    httpClient
+	retry      *RetryPolicy
 }
 
-func unmarshalError(data []byte) (string, error) {
-	var e struct {
-		Error   string `json:"error"`
-		Message string `json:"message"`
+// do sends the request and, if the service responds successfully,
+// decodes the JSON response body into v. Unsuccessful responses are
+// returned as errors wrapping an *Error. Requests failing with a
+// transient error are retried according to the client's retry policy.
+func (c *client) do(ctx context.Context, req *http.Request, v interface{}) error {
+	req = requestWithId(ctx, req.WithContext(ctx))
+	attempts := c.retry.attempts(req)
+	for attempt := 1; ; attempt++ {
+		retryAfter, transient, err := c.do1(ctx, req, v)
+		if err == nil || !transient || attempt >= attempts {
+			return err
+		}
+		if !wait(ctx, c.retry.delay(attempt, retryAfter)) {
+			return err
+		}
+		if req.GetBody != nil {
+			body, berr := req.GetBody()
+			if berr != nil {
+				return errors.Trace(berr)
+			}
+			req.Body = body
+		}
 	}
-	err := json.Unmarshal(data, &e)
+}
+
+// do1 sends the request once. Along with the error, it returns
+// whether the failure is transient and the delay the service asked
+// for before the request is retried.
+func (c *client) do1(ctx context.Context, req *http.Request, v interface{}) (time.Duration, bool, error) {
+	response, err := c.bclient.Do(req)
 	if err != nil {
-		return "", errors.Trace(err)
+		return 0, isTransientError(ctx, err), errors.Trace(err)
+	}
+	defer httperror.DiscardClose(response)
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return 0, isTransientError(ctx, err), errors.Trace(err)
+	}
+	if response.StatusCode != http.StatusOK {
+		retryAfter := parseRetryAfter(response.Header.Get("Retry-After"))
+		return retryAfter, isTransientStatus(response.StatusCode), newError(req, response, data)
 	}
-	if e.Error != "" {
-		return e.Error, nil
+	err = json.Unmarshal(data, v)
+	if err != nil {
+		return 0, false, errors.Trace(err)
 	}
-	return e.Message, nil
+	return 0, false, nil
 }
 
 // Publish publishes the owned term identified by input parameters
 // and returns the published term id.
 func (c *client) Publish(ctx context.Context, owner, name string, revision int) (string, error) {
-	fail := func(err error) (string, error) {
-		return "", err
-	}
 	if owner == "" {
 		return fmt.Sprintf("%s/%d", name, revision), nil
 	}
@@ -136,32 +178,12 @@ func (c *client) Publish(ctx context.Context, owner, name string, revision int)
 
 	req, err := http.NewRequest("POST", termURL, nil)
 	if err != nil {
-		return fail(errors.Trace(err))
-	}
-	req = requestWithId(ctx, req)
-
-	response, err := c.bclient.Do(req)
-	if err != nil {
-		return fail(errors.Trace(err))
-	}
-	defer discardClose(response)
-	data, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		return fail(errors.Trace(err))
-	}
-	if response.StatusCode != http.StatusOK {
-		message, uerr := unmarshalError(data)
-		if uerr != nil {
-			return fail(errors.New(string(data)))
-		}
-		return fail(errors.New(message))
-	}
-	var id struct {
-		TermID string `json:"term-id"`
+		return "", errors.Trace(err)
 	}
-	err = json.Unmarshal(data, &id)
+	var id wireformat.TermIDResponse
+	err = c.do(ctx, req, &id)
 	if err != nil {
-		return fail(errors.Trace(err))
+		return "", errors.Trace(err)
 	}
 	return id.TermID, nil
 }
@@ -179,26 +201,8 @@ func (c *client) GetTerm(ctx context.Context, owner, name string, revision int)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
-	req = requestWithId(ctx, req)
-
-	response, err := c.bclient.Do(req)
-	if err != nil {
-		return nil, errors.Trace(err)
-	}
-	defer discardClose(response)
-	data, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		return nil, errors.Trace(err)
-	}
-	if response.StatusCode != http.StatusOK {
-		message, uerr := unmarshalError(data)
-		if uerr != nil {
-			return nil, errors.New(string(data))
-		}
-		return nil, errors.New(message)
-	}
 	var terms []wireformat.Term
-	err = json.Unmarshal(data, &terms)
+	err = c.do(ctx, req, &terms)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
@@ -225,31 +229,13 @@ func (c *client) SaveTerm(ctx context.Context, owner, name, content string) (str
 		return "", errors.Trace(err)
 	}
 
-	req, err := http.NewRequest("POST", termURL.String(), bytes.NewReader(data))
+	req, err := newRequest("POST", termURL.String(), data)
 	if err != nil {
 		return "", errors.Trace(err)
 	}
 	req.Header.Set("Content-Type", "application/json")
-	req = requestWithId(ctx, req)
-
-	response, err := c.bclient.Do(req)
-	if err != nil {
-		return "", errors.Trace(err)
-	}
-	defer discardClose(response)
-	data, err = ioutil.ReadAll(response.Body)
-	if err != nil {
-		return "", errors.Trace(err)
-	}
-	if response.StatusCode != http.StatusOK {
-		message, uerr := unmarshalError(data)
-		if uerr != nil {
-			return "", errors.New(string(data))
-		}
-		return "", errors.New(message)
-	}
 	var savedTerm wireformat.TermIDResponse
-	err = json.Unmarshal(data, &savedTerm)
+	err = c.do(ctx, req, &savedTerm)
 	if err != nil {
 		return "", errors.Trace(err)
 	}
@@ -264,26 +250,10 @@ func (c *client) GetUsersAgreements(ctx context.Context) ([]wireformat.Agreement
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
-	req = requestWithId(ctx, req)
-
-	response, err := c.bclient.Do(req)
-	if err != nil {
-		return nil, errors.Trace(err)
-	}
-	if response.StatusCode != http.StatusOK {
-		b, err := ioutil.ReadAll(response.Body)
-		if err != nil {
-			return nil, errors.Errorf("failed to get signed agreements: %v", response.Status)
-		}
-		return nil, errors.Errorf("failed to get signed agreements: %v: %s", response.Status, string(b))
-	}
-	defer discardClose(response)
-
 	var results []wireformat.AgreementResponse
-	dec := json.NewDecoder(response.Body)
-	err = dec.Decode(&results)
+	err = c.do(ctx, req, &results)
 	if err != nil {
-		return nil, errors.Trace(err)
+		return nil, errors.Annotate(err, "failed to get signed agreements")
 	}
 	return results, nil
 }
@@ -296,37 +266,15 @@ func (c *client) SaveAgreement(ctx context.Context, request *wireformat.SaveAgre
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
-	req, err := http.NewRequest("POST", u, bytes.NewReader(data))
+	req, err := newRequest("POST", u, data)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
 	req.Header.Set("Content-Type", "application/json")
-	req = requestWithId(ctx, req)
-
-	response, err := c.bclient.Do(req)
-	if err != nil {
-		return nil, errors.Trace(err)
-	}
-	if response.StatusCode != http.StatusOK {
-		b, err := ioutil.ReadAll(response.Body)
-		if err != nil {
-			return nil, errors.Errorf("failed to save agreement: %v", response.Status)
-		}
-		var e struct {
-			Error string `json:"error"`
-			Code  string `json:"code"`
-		}
-		if err = json.Unmarshal(b, &e); err != nil {
-			return nil, errors.Errorf("%v: %s", response.Status, string(b))
-		}
-		return nil, errors.Errorf("failed to save agreement: %v: %s", e.Code, e.Error)
-	}
-	defer discardClose(response)
 	var results wireformat.SaveAgreementResponses
-	dec := json.NewDecoder(response.Body)
-	err = dec.Decode(&results)
+	err = c.do(ctx, req, &results)
 	if err != nil {
-		return nil, errors.Trace(err)
+		return nil, errors.Annotate(err, "failed to save agreement")
 	}
 	return &results, nil
 }
@@ -345,25 +293,10 @@ func (c *client) GetUnsignedTerms(ctx context.Context, terms *wireformat.CheckAg
 		return nil, errors.Trace(err)
 	}
 	req.Header.Set("Content-Type", "application/json")
-	req = requestWithId(ctx, req)
-
-	response, err := c.bclient.Do(req)
-	if err != nil {
-		return nil, errors.Trace(err)
-	}
-	if response.StatusCode != http.StatusOK {
-		b, err := ioutil.ReadAll(response.Body)
-		if err != nil {
-			return nil, errors.Errorf("failed to get unsigned terms: %v", response.Status)
-		}
-		return nil, errors.Errorf("failed to get unsigned terms: %v: %s", response.Status, string(b))
-	}
-	defer discardClose(response)
 	var results []wireformat.GetTermsResponse
-	dec := json.NewDecoder(response.Body)
-	err = dec.Decode(&results)
+	err = c.do(ctx, req, &results)
 	if err != nil {
-		return nil, errors.Trace(err)
+		return nil, errors.Annotate(err, "failed to get unsigned terms")
 	}
 	return results, nil
 }
@@ -374,30 +307,50 @@ func (c *client) GetTermsByOwner(ctx context.Context, owner string) ([]wireforma
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
-	req = requestWithId(ctx, req)
-
-	response, err := c.bclient.Do(req)
+	var terms []wireformat.Term
+	err = c.do(ctx, req, &terms)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
-	defer discardClose(response)
-	data, err := ioutil.ReadAll(response.Body)
+	return terms, nil
+}
+
+// ListTermRevisions implements the Client interface. It returns all
+// revisions of the term with the specified owner and name, ordered by
+// revision number. As the terms service does not list revisions, the
+// latest revision is fetched first and all previous revisions are then
+// fetched one by one. Revisions that cannot be found are skipped.
+func (c *client) ListTermRevisions(ctx context.Context, owner, name string) ([]wireformat.Term, error) {
+	latest, err := c.GetTerm(ctx, owner, name, 0)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
-	if response.StatusCode != http.StatusOK {
-		message, uerr := unmarshalError(data)
-		if uerr != nil {
-			return nil, errors.New(string(data))
+	terms := make([]wireformat.Term, 0, latest.Revision)
+	for revision := 1; revision < latest.Revision; revision++ {
+		t, err := c.GetTerm(ctx, owner, name, revision)
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, errors.Trace(err)
 		}
-		return nil, errors.New(message)
+		terms = append(terms, *t)
 	}
-	var terms []wireformat.Term
-	err = json.Unmarshal(data, &terms)
+	return append(terms, *latest), nil
+}
+
+// DebugStatus implements the Client interface. It returns the results
+// of the checks that form the status of the terms service.
+func (c *client) DebugStatus(ctx context.Context) (*wireformat.DebugStatusResponse, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/v1/debug/status", c.serviceURL), nil)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
-	return terms, nil
+	var status wireformat.DebugStatusResponse
+	err = c.do(ctx, req, &status)
+	if err != nil {
+		return nil, errors.Annotate(err, "failed to get service status")
+	}
+	return &status, nil
 }
 
 func BaseURL() string {
@@ -429,11 +382,28 @@ func appendTermURL(baseURLStr, owner, term string, revision int) (*url.URL, erro
 	return b, nil
 }
 
-// discardClose reads any remaining data from the response body and closes it.
-func discardClose(response *http.Response) {
-	if response == nil || response.Body == nil {
-		return
+// newRequest returns a new http request with the specified body. The
+// body is kept seekable so that the bakery client is able to replay
+// the request once it has acquired the required discharge macaroons.
+func newRequest(method, url string, body []byte) (*http.Request, error) {
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	req.GetBody = func() (io.ReadCloser, error) {
+		return seekNopCloser{bytes.NewReader(body)}, nil
 	}
-	io.Copy(ioutil.Discard, response.Body)
-	response.Body.Close()
+	req.Body, _ = req.GetBody()
+	req.ContentLength = int64(len(body))
+	return req, nil
+}
+
+// seekNopCloser adds a no-op Close method to a bytes.Reader.
+type seekNopCloser struct {
+	*bytes.Reader
+}
+
+// Close implements io.Closer.
+func (seekNopCloser) Close() error {
+	return nil
 }
@@ -12,6 +12,7 @@ import (
 	stdtesting "testing"
 	"time"
 
+	"github.com/juju/errors"
 	"github.com/juju/testing"
 	jc "github.com/juju/testing/checkers"
 	gc "gopkg.in/check.v1"
@@ -173,6 +174,23 @@ func (s *apiSuite) TestGetOwnerlessTermWithoutRevision(c *gc.C) {
 	s.httpClient.CheckCall(c, 0, "Do", "https://api.jujucharms.com/terms/v1/terms/test-term")
 }
 
+func (s *apiSuite) TestListTermRevisions(c *gc.C) {
+	s.httpClient.status = http.StatusOK
+	s.httpClient.SetBody(c, []wireformat.Term{{
+		Owner:    "owner",
+		Name:     "test-term",
+		Revision: 3,
+	}})
+	terms, err := s.client.ListTermRevisions(context.Background(), "owner", "test-term")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(terms, gc.HasLen, 3)
+	s.httpClient.CheckCalls(c, []testing.StubCall{
+		{FuncName: "Do", Args: []interface{}{"https://api.jujucharms.com/terms/v1/terms/owner/test-term"}},
+		{FuncName: "Do", Args: []interface{}{"https://api.jujucharms.com/terms/v1/terms/owner/test-term?revision=1"}},
+		{FuncName: "Do", Args: []interface{}{"https://api.jujucharms.com/terms/v1/terms/owner/test-term?revision=2"}},
+	})
+}
+
 func (s *apiSuite) TestGetTermError(c *gc.C) {
 	s.httpClient.status = http.StatusInternalServerError
 	s.httpClient.SetBody(c, struct {
@@ -273,7 +291,126 @@ func (s *apiSuite) TestNotFoundError(c *gc.C) {
 			},
 		},
 	)
-	c.Assert(err, gc.ErrorMatches, "failed to get unsigned terms: Not Found: something failed")
+	c.Assert(err, gc.ErrorMatches, "failed to get unsigned terms: something failed")
+	c.Assert(errors.IsNotFound(err), jc.IsTrue)
+	serviceErr, ok := api.ServiceError(err)
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(serviceErr, jc.DeepEquals, &api.Error{
+		StatusCode: http.StatusNotFound,
+		Message:    "something failed",
+	})
+}
+
+func (s *apiSuite) TestErrors(c *gc.C) {
+	calls := []struct {
+		about string
+		call  func() error
+	}{{
+		about: "Publish",
+		call: func() error {
+			_, err := s.client.Publish(context.Background(), "owner", "test-term", 1)
+			return err
+		},
+	}, {
+		about: "GetTerm",
+		call: func() error {
+			_, err := s.client.GetTerm(context.Background(), "owner", "test-term", 1)
+			return err
+		},
+	}, {
+		about: "SaveTerm",
+		call: func() error {
+			_, err := s.client.SaveTerm(context.Background(), "owner", "test-term", "content")
+			return err
+		},
+	}, {
+		about: "GetUsersAgreements",
+		call: func() error {
+			_, err := s.client.GetUsersAgreements(context.Background())
+			return err
+		},
+	}, {
+		about: "SaveAgreement",
+		call: func() error {
+			_, err := s.client.SaveAgreement(context.Background(), &wireformat.SaveAgreements{})
+			return err
+		},
+	}, {
+		about: "GetUnsignedTerms",
+		call: func() error {
+			_, err := s.client.GetUnsignedTerms(context.Background(), &wireformat.CheckAgreementsRequest{})
+			return err
+		},
+	}, {
+		about: "GetTermsByOwner",
+		call: func() error {
+			_, err := s.client.GetTermsByOwner(context.Background(), "owner")
+			return err
+		},
+	}}
+	statuses := []struct {
+		status    int
+		satisfies func(error) bool
+	}{{
+		status:    http.StatusBadRequest,
+		satisfies: errors.IsBadRequest,
+	}, {
+		status:    http.StatusUnauthorized,
+		satisfies: errors.IsUnauthorized,
+	}, {
+		status:    http.StatusForbidden,
+		satisfies: errors.IsForbidden,
+	}, {
+		status:    http.StatusNotFound,
+		satisfies: errors.IsNotFound,
+	}, {
+		status:    http.StatusConflict,
+		satisfies: errors.IsAlreadyExists,
+	}, {
+		status: http.StatusInternalServerError,
+	}}
+	s.httpClient.header = http.Header{"X-Request-Id": []string{"test-request-id"}}
+	s.httpClient.SetBody(c, struct {
+		Code  string `json:"code"`
+		Error string `json:"error"`
+	}{
+		Code:  "test code",
+		Error: "test error",
+	})
+	for _, call := range calls {
+		for _, test := range statuses {
+			c.Logf("running %s with status %d", call.about, test.status)
+			s.httpClient.status = test.status
+			err := call.call()
+			c.Assert(err, gc.ErrorMatches, "(.*: )?test error")
+			if test.satisfies != nil {
+				c.Assert(test.satisfies(err), jc.IsTrue)
+			}
+			serviceErr, ok := api.ServiceError(err)
+			c.Assert(ok, jc.IsTrue)
+			c.Assert(serviceErr, jc.DeepEquals, &api.Error{
+				StatusCode: test.status,
+				Code:       "test code",
+				Message:    "test error",
+				RequestID:  "test-request-id",
+			})
+		}
+	}
+}
+
+func (s *apiSuite) TestErrorRequestID(c *gc.C) {
+	s.httpClient.status = http.StatusInternalServerError
+	s.httpClient.body = nil
+	ctx := context.WithValue(context.Background(), "X-Request-ID", "test-id")
+	_, err := s.client.GetTerm(ctx, "owner", "test-term", 1)
+	c.Assert(err, gc.ErrorMatches, "Internal Server Error")
+	serviceErr, ok := api.ServiceError(err)
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(serviceErr, jc.DeepEquals, &api.Error{
+		StatusCode: http.StatusInternalServerError,
+		Message:    "Internal Server Error",
+		RequestID:  "test-id",
+	})
 }
 
 func (s *apiSuite) TestSignedAgreementsEnvTermsURL(c *gc.C) {
@@ -386,9 +523,47 @@ func (s *apiSuite) TestGetTermsByOwnerRequestError(c *gc.C) {
 	s.httpClient.CheckCall(c, 0, "Do", "https://api.jujucharms.com/terms/v1/g/test-user")
 }
 
+func (s *apiSuite) TestDebugStatus(c *gc.C) {
+	status := wireformat.DebugStatusResponse{
+		Checks: map[string]wireformat.CheckResult{
+			"mongo_connected": {
+				Name:     "MongoDB is connected",
+				Value:    "Connected",
+				Passed:   true,
+				Duration: 3 * time.Millisecond,
+			},
+			"server_started": {
+				Name:   "Server started",
+				Value:  "2016-01-02 04:08:16 +0000 UTC",
+				Passed: true,
+			},
+		},
+	}
+	s.httpClient.status = http.StatusOK
+	s.httpClient.SetBody(c, status)
+	result, err := s.client.DebugStatus(context.Background())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result, jc.DeepEquals, &status)
+	s.httpClient.CheckCall(c, 0, "Do", "https://api.jujucharms.com/terms/v1/debug/status")
+}
+
+func (s *apiSuite) TestDebugStatusRequestError(c *gc.C) {
+	s.httpClient.status = http.StatusInternalServerError
+	s.httpClient.SetBody(c, struct {
+		Code  string `json:"code"`
+		Error string `json:"error"`
+	}{
+		Code:  "internal error",
+		Error: "database unavailable",
+	})
+	_, err := s.client.DebugStatus(context.Background())
+	c.Assert(err, gc.ErrorMatches, "failed to get service status: .*database unavailable")
+}
+
 type mockHttpClient struct {
 	testing.Stub
 	status int
+	header http.Header
 	body   []byte
 }
 
@@ -405,6 +580,7 @@ func (m *mockHttpClient) Do(req *http.Request) (*http.Response, error) {
 		Proto:      "HTTP/1.0",
 		ProtoMajor: 1,
 		ProtoMinor: 1,
+		Header:     m.header,
 		Body:       ioutil.NopCloser(bytes.NewReader(m.body)),
 	}, nil
 }
@@ -0,0 +1,189 @@
+// Copyright 2020 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/juju/charm/v8"
+	"github.com/juju/errors"
+
+	"github.com/juju/terms-client/api/wireformat"
+)
+
+// BundleVersion holds the version of the agreement bundle format
+// written by this package.
+const BundleVersion = 1
+
+// Bundle holds a snapshot of the terms a user has agreed to, so
+// that agreements may be verified where the terms service is not
+// reachable.
+type Bundle struct {
+	// Version holds the version of the bundle format.
+	Version int `json:"version"`
+
+	// User holds the name of the user that made the agreements.
+	User string `json:"user,omitempty"`
+
+	// CreatedOn holds the time the bundle was created.
+	CreatedOn time.Time `json:"created-on"`
+
+	// Terms holds the terms included in the bundle.
+	Terms []wireformat.Term `json:"terms"`
+
+	// Agreements holds the agreements made by the user.
+	Agreements []wireformat.AgreementResponse `json:"agreements"`
+}
+
+// ReadBundle reads an agreement bundle from r.
+func ReadBundle(r io.Reader) (*Bundle, error) {
+	var b Bundle
+	if err := json.NewDecoder(r).Decode(&b); err != nil {
+		return nil, errors.Annotate(err, "invalid agreement bundle")
+	}
+	if b.Version != BundleVersion {
+		return nil, errors.NotSupportedf("agreement bundle version %d", b.Version)
+	}
+	return &b, nil
+}
+
+// Write writes the bundle to w.
+func (b *Bundle) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return errors.Trace(enc.Encode(b))
+}
+
+// term returns the specified revision of the term in the bundle, where
+// revision 0 denotes the latest revision included in the bundle.
+func (b *Bundle) term(owner, name string, revision int) (*wireformat.Term, bool) {
+	var found *wireformat.Term
+	for i, t := range b.Terms {
+		if t.Owner != owner || t.Name != name {
+			continue
+		}
+		if t.Revision == revision {
+			return &b.Terms[i], true
+		}
+		if revision == 0 && (found == nil || t.Revision > found.Revision) {
+			found = &b.Terms[i]
+		}
+	}
+	return found, found != nil
+}
+
+// agreed reports whether the bundle records an agreement to the
+// specified revision of the term.
+func (b *Bundle) agreed(owner, name string, revision int) bool {
+	for _, a := range b.Agreements {
+		if a.Owner == owner && a.Term == name && a.Revision == revision {
+			return true
+		}
+	}
+	return false
+}
+
+// NewBundleClient returns a client that answers GetTerm,
+// GetUnsignedTerms and GetUsersAgreements from the given bundle
+// without contacting the terms service. All other calls fail with
+// an error satisfying errors.IsNotSupported.
+func NewBundleClient(b *Bundle) Client {
+	return &bundleClient{bundle: b}
+}
+
+type bundleClient struct {
+	bundle *Bundle
+}
+
+// SaveTerm implements Client.SaveTerm.
+func (c *bundleClient) SaveTerm(ctx context.Context, owner, name, content string) (string, error) {
+	return "", errors.NotSupportedf("saving terms offline")
+}
+
+// GetTerm implements Client.GetTerm.
+func (c *bundleClient) GetTerm(ctx context.Context, owner, name string, revision int) (*wireformat.Term, error) {
+	t, ok := c.bundle.term(owner, name, revision)
+	if !ok {
+		return nil, errors.NotFoundf("term %q in agreement bundle", bundleTermID(owner, name, revision))
+	}
+	result := *t
+	return &result, nil
+}
+
+// GetUnsignedTerms implements Client.GetUnsignedTerms. Terms with a
+// revision that are not included in the bundle are reported without
+// their content.
+func (c *bundleClient) GetUnsignedTerms(ctx context.Context, terms *wireformat.CheckAgreementsRequest) ([]wireformat.GetTermsResponse, error) {
+	results := []wireformat.GetTermsResponse{}
+	for _, id := range terms.Terms {
+		termID, err := charm.ParseTerm(id)
+		if err != nil {
+			return nil, errors.NewBadRequest(err, "")
+		}
+		response := wireformat.GetTermsResponse{
+			Owner:    termID.Owner,
+			Name:     termID.Name,
+			Revision: termID.Revision,
+		}
+		if t, ok := c.bundle.term(termID.Owner, termID.Name, termID.Revision); ok {
+			response.Revision = t.Revision
+			response.Title = t.Title
+			response.CreatedOn = time.Time(t.CreatedOn)
+			response.Content = t.Content
+		} else if termID.Revision == 0 {
+			return nil, errors.NotFoundf("term %q in agreement bundle", id)
+		}
+		if c.bundle.agreed(response.Owner, response.Name, response.Revision) {
+			continue
+		}
+		results = append(results, response)
+	}
+	return results, nil
+}
+
+// SaveAgreement implements Client.SaveAgreement.
+func (c *bundleClient) SaveAgreement(ctx context.Context, agreements *wireformat.SaveAgreements) (*wireformat.SaveAgreementResponses, error) {
+	return nil, errors.NotSupportedf("saving agreements offline")
+}
+
+// GetUsersAgreements implements Client.GetUsersAgreements.
+func (c *bundleClient) GetUsersAgreements(ctx context.Context) ([]wireformat.AgreementResponse, error) {
+	return append([]wireformat.AgreementResponse{}, c.bundle.Agreements...), nil
+}
+
+// Publish implements Client.Publish.
+func (c *bundleClient) Publish(ctx context.Context, owner, name string, revision int) (string, error) {
+	return "", errors.NotSupportedf("publishing terms offline")
+}
+
+// GetTermsByOwner implements Client.GetTermsByOwner.
+func (c *bundleClient) GetTermsByOwner(ctx context.Context, owner string) ([]wireformat.Term, error) {
+	return nil, errors.NotSupportedf("listing terms offline")
+}
+
+// ListTermRevisions implements Client.ListTermRevisions.
+func (c *bundleClient) ListTermRevisions(ctx context.Context, owner, name string) ([]wireformat.Term, error) {
+	return nil, errors.NotSupportedf("listing term revisions offline")
+}
+
+// DebugStatus implements Client.DebugStatus.
+func (c *bundleClient) DebugStatus(ctx context.Context) (*wireformat.DebugStatusResponse, error) {
+	return nil, errors.NotSupportedf("checking the service status offline")
+}
+
+// bundleTermID returns the identifier of the specified term revision.
+func bundleTermID(owner, name string, revision int) string {
+	id := name
+	if owner != "" {
+		id = owner + "/" + name
+	}
+	if revision != 0 {
+		id = fmt.Sprintf("%s/%d", id, revision)
+	}
+	return id
+}
@@ -0,0 +1,180 @@
+// Copyright 2020 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/terms-client/api"
+	"github.com/juju/terms-client/api/wireformat"
+)
+
+type bundleSuite struct {
+	bundle *api.Bundle
+	client api.Client
+}
+
+var _ = gc.Suite(&bundleSuite{})
+
+func (s *bundleSuite) SetUpTest(c *gc.C) {
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	s.bundle = &api.Bundle{
+		Version:   api.BundleVersion,
+		User:      "test-user",
+		CreatedOn: created,
+		Terms: []wireformat.Term{{
+			Id:        "owner/test-term/1",
+			Owner:     "owner",
+			Name:      "test-term",
+			Revision:  1,
+			CreatedOn: wireformat.TimeRFC3339(created),
+			Published: true,
+			Content:   "revision 1",
+		}, {
+			Id:        "owner/test-term/2",
+			Owner:     "owner",
+			Name:      "test-term",
+			Revision:  2,
+			CreatedOn: wireformat.TimeRFC3339(created),
+			Published: true,
+			Content:   "revision 2",
+		}, {
+			Id:        "ownerless-term/3",
+			Name:      "ownerless-term",
+			Revision:  3,
+			CreatedOn: wireformat.TimeRFC3339(created),
+			Published: true,
+			Content:   "ownerless",
+		}},
+		Agreements: []wireformat.AgreementResponse{{
+			User:      "test-user",
+			Owner:     "owner",
+			Term:      "test-term",
+			Revision:  1,
+			CreatedOn: created,
+		}, {
+			User:      "test-user",
+			Term:      "ownerless-term",
+			Revision:  3,
+			CreatedOn: created,
+		}},
+	}
+	s.client = api.NewBundleClient(s.bundle)
+}
+
+func (s *bundleSuite) TestReadWrite(c *gc.C) {
+	var buf bytes.Buffer
+	err := s.bundle.Write(&buf)
+	c.Assert(err, jc.ErrorIsNil)
+	b, err := api.ReadBundle(&buf)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(b, jc.DeepEquals, s.bundle)
+}
+
+func (s *bundleSuite) TestReadInvalid(c *gc.C) {
+	_, err := api.ReadBundle(strings.NewReader("not json"))
+	c.Assert(err, gc.ErrorMatches, "invalid agreement bundle: .*")
+
+	_, err = api.ReadBundle(strings.NewReader(`{"version":2}`))
+	c.Assert(err, gc.ErrorMatches, "agreement bundle version 2 not supported")
+	c.Assert(err, jc.Satisfies, errors.IsNotSupported)
+}
+
+func (s *bundleSuite) TestGetTerm(c *gc.C) {
+	t, err := s.client.GetTerm(context.Background(), "owner", "test-term", 1)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(t.Content, gc.Equals, "revision 1")
+
+	t, err = s.client.GetTerm(context.Background(), "owner", "test-term", 0)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(t.Content, gc.Equals, "revision 2")
+
+	t, err = s.client.GetTerm(context.Background(), "", "ownerless-term", 0)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(t.Content, gc.Equals, "ownerless")
+
+	_, err = s.client.GetTerm(context.Background(), "owner", "test-term", 3)
+	c.Assert(err, gc.ErrorMatches, `term "owner/test-term/3" in agreement bundle not found`)
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *bundleSuite) TestGetUnsignedTerms(c *gc.C) {
+	tests := []struct {
+		about    string
+		terms    []string
+		err      string
+		expected []wireformat.GetTermsResponse
+	}{{
+		about:    "all agreed",
+		terms:    []string{"owner/test-term/1", "ownerless-term/3"},
+		expected: []wireformat.GetTermsResponse{},
+	}, {
+		about: "latest revision not agreed",
+		terms: []string{"owner/test-term/1", "owner/test-term"},
+		expected: []wireformat.GetTermsResponse{{
+			Owner:     "owner",
+			Name:      "test-term",
+			Revision:  2,
+			CreatedOn: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+			Content:   "revision 2",
+		}},
+	}, {
+		about: "term not in bundle",
+		terms: []string{"owner/other-term/5"},
+		expected: []wireformat.GetTermsResponse{{
+			Owner:    "owner",
+			Name:     "other-term",
+			Revision: 5,
+		}},
+	}, {
+		about: "latest revision not in bundle",
+		terms: []string{"owner/other-term"},
+		err:   `term "owner/other-term" in agreement bundle not found`,
+	}, {
+		about: "invalid term",
+		terms: []string{"!!!"},
+		err:   `.*wrong term name format "!!!"`,
+	}}
+	for i, test := range tests {
+		c.Logf("running test %d: %s", i, test.about)
+		unsigned, err := s.client.GetUnsignedTerms(context.Background(), &wireformat.CheckAgreementsRequest{
+			Terms: test.terms,
+		})
+		if test.err != "" {
+			c.Assert(err, gc.ErrorMatches, test.err)
+			continue
+		}
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(unsigned, jc.DeepEquals, test.expected)
+	}
+}
+
+func (s *bundleSuite) TestGetUsersAgreements(c *gc.C) {
+	agreements, err := s.client.GetUsersAgreements(context.Background())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(agreements, jc.DeepEquals, s.bundle.Agreements)
+}
+
+func (s *bundleSuite) TestNotSupported(c *gc.C) {
+	ctx := context.Background()
+	_, err := s.client.SaveTerm(ctx, "owner", "test-term", "content")
+	c.Check(err, jc.Satisfies, errors.IsNotSupported)
+	_, err = s.client.SaveAgreement(ctx, &wireformat.SaveAgreements{})
+	c.Check(err, jc.Satisfies, errors.IsNotSupported)
+	_, err = s.client.Publish(ctx, "owner", "test-term", 1)
+	c.Check(err, jc.Satisfies, errors.IsNotSupported)
+	_, err = s.client.GetTermsByOwner(ctx, "owner")
+	c.Check(err, jc.Satisfies, errors.IsNotSupported)
+	_, err = s.client.ListTermRevisions(ctx, "owner", "test-term")
+	c.Check(err, jc.Satisfies, errors.IsNotSupported)
+	_, err = s.client.DebugStatus(ctx)
+	c.Check(err, jc.Satisfies, errors.IsNotSupported)
+}
@@ -0,0 +1,212 @@
+// Copyright 2020 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+
+	"github.com/juju/terms-client/api/wireformat"
+)
+
+// DefaultCacheTTL holds the default duration for which the latest
+// revision of a term is served from the cache before being revalidated
+// with the terms service.
+const DefaultCacheTTL = 10 * time.Minute
+
+// NewCachingClient returns a client that stores the terms fetched by
+// GetTerm in the given directory, separately for each terms service
+// URL so that terms fetched from different services never mix.
+// Published revisions never change, so lookups of a specific published
+// revision are served from the cache without contacting the terms
+// service. Lookups of the latest revision are served from the cache
+// for at most ttl, after which the terms service is queried again, or
+// until a new revision is saved or published through the returned
+// client. All other calls are passed to client.
+//
+// Failures to read or write the cache are not reported: the terms
+// service is used instead.
+func NewCachingClient(client Client, dir, serviceURL string, ttl time.Duration) Client {
+	return &cachingClient{
+		Client: client,
+		dir:    filepath.Join(dir, serviceDir(serviceURL)),
+		ttl:    ttl,
+		now:    time.Now,
+	}
+}
+
+// NewCacheInvalidatingClient returns a client that never serves terms
+// from the cache in the given directory, but that discards the cached
+// latest revision of a term when a new revision of it is saved or
+// published through the returned client, as the client returned by
+// NewCachingClient does. It is meant for clients that must see the
+// latest state of the terms service but share the cache with caching
+// clients.
+func NewCacheInvalidatingClient(client Client, dir, serviceURL string) Client {
+	return &cacheInvalidatingClient{
+		cachingClient: &cachingClient{
+			Client: client,
+			dir:    filepath.Join(dir, serviceDir(serviceURL)),
+			now:    time.Now,
+		},
+	}
+}
+
+type cacheInvalidatingClient struct {
+	*cachingClient
+}
+
+// GetTerm implements Client.GetTerm. The cache is not used.
+func (c *cacheInvalidatingClient) GetTerm(ctx context.Context, owner, name string, revision int) (*wireformat.Term, error) {
+	return c.Client.GetTerm(ctx, owner, name, revision)
+}
+
+type cachingClient struct {
+	Client
+	dir string
+	ttl time.Duration
+	now func() time.Time
+}
+
+// cacheEntry holds a term stored in the cache.
+type cacheEntry struct {
+	Term    wireformat.Term `json:"term"`
+	Fetched time.Time       `json:"fetched"`
+}
+
+// GetTerm implements Client.GetTerm.
+func (c *cachingClient) GetTerm(ctx context.Context, owner, name string, revision int) (*wireformat.Term, error) {
+	if !isPathElement(owner) || !isPathElement(name) {
+		return c.Client.GetTerm(ctx, owner, name, revision)
+	}
+	path := c.path(owner, name, revision)
+	if entry, err := c.read(path); err == nil {
+		if revision != 0 || c.now().Sub(entry.Fetched) < c.ttl {
+			return &entry.Term, nil
+		}
+	}
+	term, err := c.Client.GetTerm(ctx, owner, name, revision)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if revision == 0 {
+		c.write(path, term)
+	}
+	if term.Published {
+		c.write(c.path(owner, name, term.Revision), term)
+	}
+	return term, nil
+}
+
+// SaveTerm implements Client.SaveTerm. The cached latest revision of
+// the term is discarded as it is no longer the latest one.
+func (c *cachingClient) SaveTerm(ctx context.Context, owner, name, content string) (string, error) {
+	id, err := c.Client.SaveTerm(ctx, owner, name, content)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	c.invalidate(owner, name)
+	return id, nil
+}
+
+// Publish implements Client.Publish. The cached latest revision of the
+// term is discarded as its release status may have changed.
+func (c *cachingClient) Publish(ctx context.Context, owner, name string, revision int) (string, error) {
+	id, err := c.Client.Publish(ctx, owner, name, revision)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	c.invalidate(owner, name)
+	return id, nil
+}
+
+// invalidate removes the cached latest revision of the term.
+func (c *cachingClient) invalidate(owner, name string) {
+	if !isPathElement(owner) || !isPathElement(name) {
+		return
+	}
+	os.Remove(c.path(owner, name, 0))
+}
+
+// serviceDir returns the name of the cache directory holding the terms
+// fetched from the terms service at the given URL. The URL is escaped
+// so that it forms a single valid file name on all platforms.
+func serviceDir(serviceURL string) string {
+	dir := url.QueryEscape(strings.TrimSuffix(serviceURL, "/"))
+	if !isPathElement(dir) {
+		// Service URLs cannot start with an underscore.
+		return "_" + dir
+	}
+	return dir
+}
+
+// path returns the path of the cache file holding the specified
+// revision of the term, where revision 0 denotes the latest revision.
+func (c *cachingClient) path(owner, name string, revision int) string {
+	if owner == "" {
+		// Owner names cannot start with an underscore.
+		owner = "_"
+	}
+	file := "latest.json"
+	if revision != 0 {
+		file = strconv.Itoa(revision) + ".json"
+	}
+	return filepath.Join(c.dir, owner, name, file)
+}
+
+// isPathElement reports whether s can be safely used as a single
+// element of a cache path.
+func isPathElement(s string) bool {
+	return s != "." && s != ".." && !strings.ContainsAny(s, `/\`)
+}
+
+// read returns the cache entry stored in the given file.
+func (c *cachingClient) read(path string) (*cacheEntry, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &entry, nil
+}
+
+// write stores the term in the given file. The file is replaced
+// atomically so that concurrent readers never see a partial entry.
+func (c *cachingClient) write(path string, term *wireformat.Term) error {
+	data, err := json.Marshal(cacheEntry{
+		Term:    *term,
+		Fetched: c.now(),
+	})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return errors.Trace(err)
+	}
+	f, err := ioutil.TempFile(filepath.Dir(path), ".tmp-")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(os.Rename(f.Name(), path))
+}
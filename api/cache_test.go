@@ -0,0 +1,216 @@
+// Copyright 2020 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package api_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/terms-client/api"
+	"github.com/juju/terms-client/api/termstest"
+	"github.com/juju/terms-client/api/wireformat"
+)
+
+type cacheSuite struct {
+	server *termstest.Server
+	stub   *countingClient
+	dir    string
+	client api.Client
+	now    time.Time
+}
+
+var _ = gc.Suite(&cacheSuite{})
+
+func (s *cacheSuite) SetUpTest(c *gc.C) {
+	s.server = termstest.NewServer()
+	s.stub = &countingClient{Client: s.server.Client()}
+	s.dir = c.MkDir()
+	s.client = api.NewCachingClient(s.stub, s.dir, "https://terms.example.com/", time.Hour)
+	s.now = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	api.SetCacheClock(s.client, func() time.Time { return s.now })
+}
+
+func (s *cacheSuite) TestExactRevision(c *gc.C) {
+	s.server.AddTerm("test-owner", "test-term", "content 1", true)
+	for i := 0; i < 3; i++ {
+		t, err := s.client.GetTerm(context.Background(), "test-owner", "test-term", 1)
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(t.Content, gc.Equals, "content 1")
+	}
+	s.stub.CheckCalls(c, []testing.StubCall{
+		{FuncName: "GetTerm", Args: []interface{}{"test-owner", "test-term", 1}},
+	})
+
+	// A cached revision is served even when the service is unavailable.
+	s.stub.SetErrors(errors.New("service unavailable"))
+	s.now = s.now.Add(24 * time.Hour)
+	t, err := s.client.GetTerm(context.Background(), "test-owner", "test-term", 1)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(t.Content, gc.Equals, "content 1")
+	c.Assert(s.stub.Calls(), gc.HasLen, 1)
+}
+
+func (s *cacheSuite) TestUnpublishedRevisionNotCached(c *gc.C) {
+	s.server.AddTerm("test-owner", "test-term", "content 1", false)
+	for i := 0; i < 2; i++ {
+		_, err := s.client.GetTerm(context.Background(), "test-owner", "test-term", 1)
+		c.Assert(err, jc.ErrorIsNil)
+	}
+	c.Assert(s.stub.Calls(), gc.HasLen, 2)
+}
+
+func (s *cacheSuite) TestLatestRevision(c *gc.C) {
+	s.server.AddTerm("test-owner", "test-term", "content 1", true)
+	t, err := s.client.GetTerm(context.Background(), "test-owner", "test-term", 0)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(t.Revision, gc.Equals, 1)
+
+	// The latest revision is served from the cache within the TTL.
+	s.server.AddTerm("test-owner", "test-term", "content 2", true)
+	s.now = s.now.Add(59 * time.Minute)
+	t, err = s.client.GetTerm(context.Background(), "test-owner", "test-term", 0)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(t.Revision, gc.Equals, 1)
+	c.Assert(s.stub.Calls(), gc.HasLen, 1)
+
+	// It is revalidated once the TTL expires.
+	s.now = s.now.Add(time.Minute)
+	t, err = s.client.GetTerm(context.Background(), "test-owner", "test-term", 0)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(t.Revision, gc.Equals, 2)
+	c.Assert(s.stub.Calls(), gc.HasLen, 2)
+
+	// Fetching the latest revision also caches that exact revision.
+	t, err = s.client.GetTerm(context.Background(), "test-owner", "test-term", 2)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(t.Content, gc.Equals, "content 2")
+	c.Assert(s.stub.Calls(), gc.HasLen, 2)
+}
+
+func (s *cacheSuite) TestOwnerlessTerm(c *gc.C) {
+	s.server.AddTerm("", "test-term", "content", false)
+	for i := 0; i < 2; i++ {
+		t, err := s.client.GetTerm(context.Background(), "", "test-term", 1)
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(t.Content, gc.Equals, "content")
+	}
+	c.Assert(s.stub.Calls(), gc.HasLen, 1)
+}
+
+func (s *cacheSuite) TestErrorsNotCached(c *gc.C) {
+	for i := 0; i < 2; i++ {
+		_, err := s.client.GetTerm(context.Background(), "test-owner", "test-term", 1)
+		c.Assert(errors.Cause(err), jc.Satisfies, errors.IsNotFound)
+	}
+	c.Assert(s.stub.Calls(), gc.HasLen, 2)
+}
+
+func (s *cacheSuite) TestInvalidPathNotCached(c *gc.C) {
+	for i := 0; i < 2; i++ {
+		_, err := s.client.GetTerm(context.Background(), "..", "test-term", 1)
+		c.Assert(err, gc.NotNil)
+	}
+	c.Assert(s.stub.Calls(), gc.HasLen, 2)
+}
+
+func (s *cacheSuite) TestServiceURL(c *gc.C) {
+	s.server.AddTerm("test-owner", "test-term", "content 1", true)
+	_, err := s.client.GetTerm(context.Background(), "test-owner", "test-term", 1)
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = os.Stat(filepath.Join(s.dir, "https%3A%2F%2Fterms.example.com", "test-owner", "test-term", "1.json"))
+	c.Assert(err, jc.ErrorIsNil)
+
+	// Terms cached for one service are not served for another one.
+	staging := termstest.NewServer()
+	staging.AddTerm("test-owner", "test-term", "staging content 1", true)
+	client := api.NewCachingClient(staging.Client(), s.dir, "https://terms.staging.example.com", time.Hour)
+	t, err := client.GetTerm(context.Background(), "test-owner", "test-term", 1)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(t.Content, gc.Equals, "staging content 1")
+
+	t, err = s.client.GetTerm(context.Background(), "test-owner", "test-term", 1)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(t.Content, gc.Equals, "content 1")
+	c.Assert(s.stub.Calls(), gc.HasLen, 1)
+}
+
+func (s *cacheSuite) TestSaveTermInvalidatesLatest(c *gc.C) {
+	s.server.AddTerm("test-owner", "test-term", "content 1", true)
+	t, err := s.client.GetTerm(context.Background(), "test-owner", "test-term", 0)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(t.Revision, gc.Equals, 1)
+
+	_, err = s.client.SaveTerm(context.Background(), "test-owner", "test-term", "content 2")
+	c.Assert(err, jc.ErrorIsNil)
+	t, err = s.client.GetTerm(context.Background(), "test-owner", "test-term", 0)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(t.Revision, gc.Equals, 2)
+	c.Assert(t.Published, jc.IsFalse)
+	c.Assert(s.stub.Calls(), gc.HasLen, 2)
+}
+
+func (s *cacheSuite) TestPublishInvalidatesLatest(c *gc.C) {
+	s.server.AddTerm("test-owner", "test-term", "content 1", false)
+	t, err := s.client.GetTerm(context.Background(), "test-owner", "test-term", 0)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(t.Published, jc.IsFalse)
+
+	_, err = s.client.Publish(context.Background(), "test-owner", "test-term", 1)
+	c.Assert(err, jc.ErrorIsNil)
+	t, err = s.client.GetTerm(context.Background(), "test-owner", "test-term", 0)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(t.Published, jc.IsTrue)
+	c.Assert(s.stub.Calls(), gc.HasLen, 2)
+}
+
+func (s *cacheSuite) TestCacheInvalidatingClient(c *gc.C) {
+	s.server.AddTerm("test-owner", "test-term", "content 1", false)
+	_, err := s.client.GetTerm(context.Background(), "test-owner", "test-term", 0)
+	c.Assert(err, jc.ErrorIsNil)
+
+	// The invalidating client always queries the terms service.
+	client := api.NewCacheInvalidatingClient(s.stub, s.dir, "https://terms.example.com/")
+	for i := 0; i < 2; i++ {
+		_, err = client.GetTerm(context.Background(), "test-owner", "test-term", 0)
+		c.Assert(err, jc.ErrorIsNil)
+	}
+	c.Assert(s.stub.Calls(), gc.HasLen, 3)
+
+	// Saving and publishing through it discards the cached latest
+	// revision used by the caching client.
+	_, err = client.SaveTerm(context.Background(), "test-owner", "test-term", "content 2")
+	c.Assert(err, jc.ErrorIsNil)
+	t, err := s.client.GetTerm(context.Background(), "test-owner", "test-term", 0)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(t.Revision, gc.Equals, 2)
+	c.Assert(t.Published, jc.IsFalse)
+
+	_, err = client.Publish(context.Background(), "test-owner", "test-term", 2)
+	c.Assert(err, jc.ErrorIsNil)
+	t, err = s.client.GetTerm(context.Background(), "test-owner", "test-term", 0)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(t.Published, jc.IsTrue)
+	c.Assert(s.stub.Calls(), gc.HasLen, 5)
+}
+
+// countingClient records the GetTerm calls made to the wrapped client.
+type countingClient struct {
+	api.Client
+	testing.Stub
+}
+
+func (c *countingClient) GetTerm(ctx context.Context, owner, name string, revision int) (*wireformat.Term, error) {
+	c.MethodCall(c, "GetTerm", owner, name, revision)
+	if err := c.NextErr(); err != nil {
+		return nil, err
+	}
+	return c.Client.GetTerm(ctx, owner, name, revision)
+}
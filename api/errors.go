@@ -0,0 +1,45 @@
+// Copyright 2020 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/juju/terms-client/internal/httperror"
+)
+
+// Error holds an error returned by the terms service.
+type Error = httperror.Error
+
+// ServiceError returns the terms service error found in the chain
+// of errors wrapped by err and whether one was found.
+func ServiceError(err error) (*Error, bool) {
+	return httperror.Find(err)
+}
+
+// newError returns an error describing the unsuccessful response
+// with the given body. The returned error satisfies the juju/errors
+// predicate matching the status code of the response (e.g.
+// errors.IsNotFound for http.StatusNotFound) and wraps an *Error
+// that may be obtained by calling ServiceError.
+func newError(req *http.Request, response *http.Response, data []byte) error {
+	return httperror.New(req, response, data, errorMessage)
+}
+
+// errorMessage returns the message describing an unsuccessful
+// response whose body does not hold a JSON error: the body itself
+// when it is not JSON, or the status of the response.
+func errorMessage(response *http.Response, data []byte) string {
+	if !json.Valid(data) {
+		if msg := strings.TrimSpace(string(data)); msg != "" {
+			return msg
+		}
+	}
+	if msg := http.StatusText(response.StatusCode); msg != "" {
+		return msg
+	}
+	return response.Status
+}
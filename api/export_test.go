@@ -0,0 +1,12 @@
+// Copyright 2020 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package api
+
+import "time"
+
+// SetCacheClock sets the function used by the given caching client
+// to obtain the current time.
+func SetCacheClock(client Client, now func() time.Time) {
+	client.(*cachingClient).now = now
+}
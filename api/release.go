@@ -0,0 +1,46 @@
+// Copyright 2020 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package api
+
+import (
+	"context"
+
+	"github.com/juju/charm/v8"
+	"github.com/juju/errors"
+)
+
+// ReleaseResult holds the outcome of SaveAndPublishTerm.
+type ReleaseResult struct {
+	// TermID holds the id of the saved revision.
+	TermID string `json:"term-id" yaml:"term-id"`
+
+	// ReleasedID holds the id of the released revision, or is
+	// empty if the revision was not released.
+	ReleasedID string `json:"released-id,omitempty" yaml:"released-id,omitempty"`
+}
+
+// SaveAndPublishTerm saves a new revision of the term with the given
+// content and publishes it. Nothing is rolled back when publishing
+// fails: the returned result then holds the id of the saved revision
+// along with the error.
+func SaveAndPublishTerm(ctx context.Context, client Client, owner, name, content string) (*ReleaseResult, error) {
+	termID, err := client.SaveTerm(ctx, owner, name, content)
+	if err != nil {
+		return nil, errors.Annotate(err, "failed to save term")
+	}
+	result := &ReleaseResult{TermID: termID}
+	id, err := charm.ParseTerm(termID)
+	if err != nil {
+		return result, errors.Annotatef(err, "saved term %q but cannot release it", termID)
+	}
+	if id.Revision == 0 {
+		return result, errors.Errorf("saved term %q but cannot release it: no revision returned", termID)
+	}
+	released, err := client.Publish(ctx, id.Owner, id.Name, id.Revision)
+	if err != nil {
+		return result, errors.Annotatef(err, "saved term %q but failed to release it", termID)
+	}
+	result.ReleasedID = released
+	return result, nil
+}
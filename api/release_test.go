@@ -0,0 +1,72 @@
+// Copyright 2020 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package api_test
+
+import (
+	"context"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/terms-client/api"
+	"github.com/juju/terms-client/api/termstest"
+)
+
+type releaseSuite struct{}
+
+var _ = gc.Suite(&releaseSuite{})
+
+func (s *releaseSuite) TestSaveAndPublishTerm(c *gc.C) {
+	server := termstest.NewServer()
+	server.AddTerm("owner", "test-term", "content 1", true)
+	result, err := api.SaveAndPublishTerm(context.Background(), server.Client(), "owner", "test-term", "content 2")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result, jc.DeepEquals, &api.ReleaseResult{
+		TermID:     "owner/test-term/2",
+		ReleasedID: "owner/test-term/2",
+	})
+	terms := server.Terms()
+	c.Assert(terms, gc.HasLen, 2)
+	c.Assert(terms[1].Published, jc.IsTrue)
+}
+
+func (s *releaseSuite) TestSaveAndPublishOwnerlessTerm(c *gc.C) {
+	server := termstest.NewServer()
+	result, err := api.SaveAndPublishTerm(context.Background(), server.Client(), "", "test-term", "content")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result, jc.DeepEquals, &api.ReleaseResult{
+		TermID:     "test-term/1",
+		ReleasedID: "test-term/1",
+	})
+}
+
+func (s *releaseSuite) TestSaveFails(c *gc.C) {
+	server := termstest.NewServer()
+	result, err := api.SaveAndPublishTerm(context.Background(), server.Client(), "owner", "test-term", "")
+	c.Assert(err, gc.ErrorMatches, "failed to save term: .*")
+	c.Assert(result, gc.IsNil)
+}
+
+func (s *releaseSuite) TestPublishFails(c *gc.C) {
+	server := termstest.NewServer()
+	client := &failingPublishClient{Client: server.Client()}
+	result, err := api.SaveAndPublishTerm(context.Background(), client, "owner", "test-term", "content")
+	c.Assert(err, gc.ErrorMatches, `saved term "owner/test-term/1" but failed to release it: service unavailable`)
+	c.Assert(result, jc.DeepEquals, &api.ReleaseResult{
+		TermID: "owner/test-term/1",
+	})
+	terms := server.Terms()
+	c.Assert(terms, gc.HasLen, 1)
+	c.Assert(terms[0].Published, jc.IsFalse)
+}
+
+// failingPublishClient fails all Publish calls.
+type failingPublishClient struct {
+	api.Client
+}
+
+func (c *failingPublishClient) Publish(ctx context.Context, owner, name string, revision int) (string, error) {
+	return "", errors.New("service unavailable")
+}
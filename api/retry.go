@@ -0,0 +1,154 @@
+// Copyright 2020 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package api
+
+import (
+	"context"
+	stderrors "errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+// RetryPolicy defines how requests that fail with a transient error
+// are retried.
+type RetryPolicy struct {
+	// Attempts holds the maximum number of times a request is sent.
+	Attempts int
+
+	// Delay holds the delay before the first retry. The delay doubles
+	// with every subsequent retry and a random jitter is applied.
+	Delay time.Duration
+
+	// MaxDelay holds the maximum delay between two attempts, unless
+	// the service asks for a longer one using the Retry-After header.
+	MaxDelay time.Duration
+
+	// RetryNonIdempotent specifies that requests that modify the
+	// state of the service (SaveTerm, SaveAgreement and Publish)
+	// are retried as well.
+	RetryNonIdempotent bool
+}
+
+// DefaultRetryPolicy holds the retry policy suitable for most uses.
+var DefaultRetryPolicy = RetryPolicy{
+	Attempts: 5,
+	Delay:    250 * time.Millisecond,
+	MaxDelay: 10 * time.Second,
+}
+
+// Retry returns a function that makes the API retry requests
+// that fail with a transient error according to the specified
+// policy.
+func Retry(policy RetryPolicy) ClientOption {
+	return func(h *client) {
+		h.retry = &policy
+	}
+}
+
+// attempts returns the maximum number of times the request may be sent.
+func (p *RetryPolicy) attempts(req *http.Request) int {
+	if p == nil || p.Attempts < 1 {
+		return 1
+	}
+	if req.Method != "GET" && !p.RetryNonIdempotent {
+		return 1
+	}
+	return p.Attempts
+}
+
+// delay returns the time to wait before sending the request again
+// after the specified (1-based) attempt failed.
+func (p *RetryPolicy) delay(attempt int, retryAfter time.Duration) time.Duration {
+	d := p.Delay
+	for i := 1; i < attempt && (p.MaxDelay <= 0 || d < p.MaxDelay); i++ {
+		d *= 2
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if d > 0 {
+		d = d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+	}
+	if retryAfter > d {
+		d = retryAfter
+	}
+	return d
+}
+
+// isTransientStatus reports whether a response with the given status
+// code indicates a transient failure.
+func isTransientStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// isTransientError reports whether the error returned when sending
+// a request indicates a transient failure: a timeout, a refused or
+// reset connection, or a connection closed before the response was
+// received. Other errors, such as certificate or DNS failures, are
+// permanent.
+func isTransientError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	cause := errors.Cause(err)
+	if netErr, ok := cause.(net.Error); ok && netErr.Timeout() {
+		return true
+	}
+	for _, transient := range []error{io.EOF, io.ErrUnexpectedEOF, syscall.ECONNREFUSED, syscall.ECONNRESET} {
+		if stderrors.Is(cause, transient) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRetryAfter returns the delay requested by the value of
+// a Retry-After header.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// wait waits for the specified duration. It returns false if the
+// context is done before then or its deadline would pass.
+func wait(ctx context.Context, d time.Duration) bool {
+	if deadline, ok := ctx.Deadline(); ok && time.Now().Add(d).After(deadline) {
+		return false
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
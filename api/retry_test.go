@@ -0,0 +1,249 @@
+// Copyright 2020 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package api_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/terms-client/api"
+)
+
+type retrySuite struct {
+	server    *httptest.Server
+	mu        sync.Mutex
+	responses []func(w http.ResponseWriter)
+	bodies    []string
+}
+
+var _ = gc.Suite(&retrySuite{})
+
+var testPolicy = api.RetryPolicy{
+	Attempts: 3,
+	Delay:    time.Millisecond,
+	MaxDelay: 10 * time.Millisecond,
+}
+
+func (s *retrySuite) SetUpTest(c *gc.C) {
+	s.responses = nil
+	s.bodies = nil
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, err := ioutil.ReadAll(req.Body)
+		c.Check(err, jc.ErrorIsNil)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.bodies = append(s.bodies, string(body))
+		if len(s.responses) == 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		respond := s.responses[0]
+		s.responses = s.responses[1:]
+		respond(w)
+	}))
+}
+
+func (s *retrySuite) TearDownTest(c *gc.C) {
+	s.server.Close()
+}
+
+func (s *retrySuite) respond(responses ...func(w http.ResponseWriter)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.responses = responses
+}
+
+func (s *retrySuite) requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.bodies
+}
+
+func (s *retrySuite) newClient(c *gc.C, policy api.RetryPolicy) api.Client {
+	client, err := api.NewClient(
+		api.HTTPClient(http.DefaultClient),
+		api.ServiceURL(s.server.URL),
+		api.Retry(policy),
+	)
+	c.Assert(err, jc.ErrorIsNil)
+	return client
+}
+
+func status(code int, header ...string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		for i := 0; i+1 < len(header); i += 2 {
+			w.Header().Set(header[i], header[i+1])
+		}
+		w.WriteHeader(code)
+	}
+}
+
+func body(data string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		fmt.Fprint(w, data)
+	}
+}
+
+func hangUp(w http.ResponseWriter) {
+	conn, _, err := w.(http.Hijacker).Hijack()
+	if err == nil {
+		conn.Close()
+	}
+}
+
+func (s *retrySuite) TestRetryIdempotent(c *gc.C) {
+	s.respond(
+		status(http.StatusServiceUnavailable),
+		status(http.StatusBadGateway),
+		body(`[{"name":"test-term","revision":1,"created-on":"2016-01-02T04:08:16Z"}]`),
+	)
+	term, err := s.newClient(c, testPolicy).GetTerm(context.Background(), "", "test-term", 1)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(term.Name, gc.Equals, "test-term")
+	c.Assert(s.requests(), gc.HasLen, 3)
+}
+
+func (s *retrySuite) TestRetryConnectionError(c *gc.C) {
+	s.respond(
+		hangUp,
+		body(`[]`),
+	)
+	_, err := s.newClient(c, testPolicy).GetUsersAgreements(context.Background())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.requests(), gc.HasLen, 2)
+}
+
+func (s *retrySuite) TestNoRetryCertificateError(c *gc.C) {
+	var mu sync.Mutex
+	var conns int
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		c.Errorf("unexpected request")
+	}))
+	server.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			mu.Lock()
+			defer mu.Unlock()
+			conns++
+		}
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	// The server certificate is not trusted by the default client.
+	client, err := api.NewClient(
+		api.HTTPClient(http.DefaultClient),
+		api.ServiceURL(server.URL),
+		api.Retry(testPolicy),
+	)
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = client.GetUsersAgreements(context.Background())
+	c.Assert(err, gc.ErrorMatches, `.*certificate.*`)
+	mu.Lock()
+	defer mu.Unlock()
+	c.Assert(conns, gc.Equals, 1)
+}
+
+func (s *retrySuite) TestRetryAttemptsExhausted(c *gc.C) {
+	s.respond(
+		status(http.StatusServiceUnavailable),
+		status(http.StatusServiceUnavailable),
+		status(http.StatusServiceUnavailable),
+		body(`[]`),
+	)
+	_, err := s.newClient(c, testPolicy).GetTermsByOwner(context.Background(), "owner")
+	c.Assert(err, gc.ErrorMatches, "Service Unavailable")
+	c.Assert(s.requests(), gc.HasLen, 3)
+}
+
+func (s *retrySuite) TestNoRetryPermanentError(c *gc.C) {
+	s.respond(
+		status(http.StatusNotFound),
+		body(`[]`),
+	)
+	_, err := s.newClient(c, testPolicy).GetTermsByOwner(context.Background(), "owner")
+	c.Assert(errors.IsNotFound(err), jc.IsTrue)
+	c.Assert(s.requests(), gc.HasLen, 1)
+}
+
+func (s *retrySuite) TestNoRetryNonIdempotent(c *gc.C) {
+	s.respond(
+		status(http.StatusServiceUnavailable),
+		body(`{"term-id":"owner/test-term/1"}`),
+	)
+	client := s.newClient(c, testPolicy)
+	_, err := client.SaveTerm(context.Background(), "owner", "test-term", "content")
+	c.Assert(err, gc.ErrorMatches, "Service Unavailable")
+	_, err = client.Publish(context.Background(), "owner", "test-term", 1)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.requests(), gc.HasLen, 2)
+}
+
+func (s *retrySuite) TestRetryNonIdempotent(c *gc.C) {
+	s.respond(
+		status(http.StatusServiceUnavailable),
+		body(`{"term-id":"owner/test-term/1"}`),
+	)
+	policy := testPolicy
+	policy.RetryNonIdempotent = true
+	id, err := s.newClient(c, policy).SaveTerm(context.Background(), "owner", "test-term", "content")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(id, gc.Equals, "owner/test-term/1")
+	c.Assert(s.requests(), jc.DeepEquals, []string{
+		`{"content":"content"}`,
+		`{"content":"content"}`,
+	})
+}
+
+func (s *retrySuite) TestRetryAfter(c *gc.C) {
+	s.respond(
+		status(http.StatusTooManyRequests, "Retry-After", "1"),
+		body(`[]`),
+	)
+	start := time.Now()
+	_, err := s.newClient(c, testPolicy).GetUsersAgreements(context.Background())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(time.Since(start) >= time.Second, jc.IsTrue)
+	c.Assert(s.requests(), gc.HasLen, 2)
+}
+
+func (s *retrySuite) TestRetryAfterExceedsDeadline(c *gc.C) {
+	s.respond(
+		status(http.StatusServiceUnavailable, "Retry-After", "60"),
+		body(`[]`),
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	start := time.Now()
+	_, err := s.newClient(c, testPolicy).GetUsersAgreements(ctx)
+	c.Assert(err, gc.ErrorMatches, "failed to get signed agreements: Service Unavailable")
+	c.Assert(time.Since(start) < 5*time.Second, jc.IsTrue)
+	c.Assert(s.requests(), gc.HasLen, 1)
+}
+
+func (s *retrySuite) TestContextCancelled(c *gc.C) {
+	s.respond(
+		status(http.StatusServiceUnavailable),
+		body(`[]`),
+	)
+	policy := testPolicy
+	policy.Delay = time.Minute
+	policy.MaxDelay = time.Minute
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	_, err := s.newClient(c, policy).GetUsersAgreements(ctx)
+	c.Assert(err, gc.ErrorMatches, "failed to get signed agreements: Service Unavailable")
+	c.Assert(s.requests(), gc.HasLen, 1)
+}
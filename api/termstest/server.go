@@ -0,0 +1,417 @@
+// Copyright 2020 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+// The termstest package provides an in-memory implementation of the
+// terms service suitable for testing terms service clients.
+package termstest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/juju/charm/v8"
+	"github.com/juju/errors"
+
+	"github.com/juju/terms-client/api"
+	"github.com/juju/terms-client/api/wireformat"
+)
+
+// DefaultUser holds the name of the user requests are made as,
+// unless changed with SetUser.
+const DefaultUser = "test-user"
+
+// requestIDHeader is the name of the header holding the request ID.
+const requestIDHeader = "X-Request-ID"
+
+// Server is an in-memory implementation of the terms service HTTP
+// API. It holds terms with their owners, revisions and publish state,
+// and agreements made by users to those terms.
+type Server struct {
+	mu         sync.Mutex
+	user       string
+	now        func() time.Time
+	terms      map[string][]wireformat.Term
+	agreements map[string][]wireformat.AgreementResponse
+	checks     map[string]wireformat.CheckResult
+}
+
+// NewServer returns a new empty terms service.
+func NewServer() *Server {
+	return &Server{
+		user:       DefaultUser,
+		now:        time.Now,
+		terms:      make(map[string][]wireformat.Term),
+		agreements: make(map[string][]wireformat.AgreementResponse),
+		checks: map[string]wireformat.CheckResult{
+			"server_started": {
+				Name:   "Server started",
+				Value:  "Started",
+				Passed: true,
+			},
+		},
+	}
+}
+
+// SetUser sets the user subsequent requests are made as.
+func (s *Server) SetUser(user string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.user = user
+}
+
+// SetNow sets the function used to determine the creation time of
+// terms and agreements.
+func (s *Server) SetNow(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
+// SetChecks sets the status checks reported by the debug/status
+// endpoint. By default a single passing "server_started" check is
+// reported.
+func (s *Server) SetChecks(checks map[string]wireformat.CheckResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checks = make(map[string]wireformat.CheckResult, len(checks))
+	for key, check := range checks {
+		s.checks[key] = check
+	}
+}
+
+// AddTerm adds a new revision of the term with the specified owner
+// and name and returns it. Owned terms are published if publish
+// is true, terms without an owner are always published.
+func (s *Server) AddTerm(owner, name, content string, publish bool) wireformat.Term {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := s.addTerm(owner, name, content)
+	if publish {
+		t = s.publish(owner, name, t.Revision)
+	}
+	return t
+}
+
+// Terms returns all revisions of all terms held by the server.
+func (s *Server) Terms() []wireformat.Term {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var terms []wireformat.Term
+	for _, revisions := range s.terms {
+		terms = append(terms, revisions...)
+	}
+	sort.Sort(wireformat.Terms(terms))
+	return terms
+}
+
+// Agreements returns all agreements made by the specified user.
+func (s *Server) Agreements(user string) []wireformat.AgreementResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]wireformat.AgreementResponse(nil), s.agreements[user]...)
+}
+
+// Client returns a terms service client that sends requests directly
+// to the server, without using the network. Any options are applied
+// after the ones configuring the client to use the server.
+func (s *Server) Client(options ...api.ClientOption) api.Client {
+	options = append([]api.ClientOption{
+		api.HTTPClient(handlerClient{s}),
+		api.ServiceURL("http://terms.example.com"),
+	}, options...)
+	client, err := api.NewClient(options...)
+	if err != nil {
+		panic(err)
+	}
+	return client
+}
+
+// handlerClient implements an HTTP client by serving requests
+// with an http.Handler.
+type handlerClient struct {
+	handler http.Handler
+}
+
+// Do sends the request to the handler and returns its response.
+func (c handlerClient) Do(req *http.Request) (*http.Response, error) {
+	recorder := httptest.NewRecorder()
+	c.handler.ServeHTTP(recorder, req)
+	return recorder.Result(), nil
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if id := req.Header.Get(requestIDHeader); id != "" {
+		w.Header().Set(requestIDHeader, id)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result, err := s.serve(req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) serve(req *http.Request) (interface{}, error) {
+	path := strings.Trim(req.URL.Path, "/")
+	parts := strings.Split(path, "/")
+	if len(parts) < 2 || parts[0] != "v1" {
+		return nil, errors.NotFoundf("%q", req.URL.Path)
+	}
+	switch {
+	case parts[1] == "terms" && req.Method == "GET" && (len(parts) == 3 || len(parts) == 4):
+		return s.getTerm(req, parts[2:])
+	case parts[1] == "terms" && req.Method == "POST" && (len(parts) == 3 || len(parts) == 4):
+		return s.saveTerm(req, parts[2:])
+	case parts[1] == "terms" && req.Method == "POST" && len(parts) == 6 && parts[5] == "publish":
+		return s.publishTerm(parts[2], parts[3], parts[4])
+	case parts[1] == "g" && req.Method == "GET" && len(parts) == 3:
+		return s.termsByOwner(parts[2]), nil
+	case parts[1] == "agreement" && req.Method == "GET" && len(parts) == 2:
+		return s.unsignedTerms(req.URL.Query()["Terms"])
+	case parts[1] == "agreement" && req.Method == "POST" && len(parts) == 2:
+		return s.saveAgreements(req)
+	case parts[1] == "agreements" && req.Method == "GET" && len(parts) == 2:
+		return s.userAgreements(), nil
+	case parts[1] == "debug" && req.Method == "GET" && len(parts) == 3 && parts[2] == "status":
+		return s.debugStatus(), nil
+	}
+	return nil, errors.NotFoundf("%s %q", req.Method, req.URL.Path)
+}
+
+func (s *Server) getTerm(req *http.Request, ownerName []string) (interface{}, error) {
+	owner, name := splitOwnerName(ownerName)
+	revision := 0
+	if r := req.URL.Query().Get("revision"); r != "" {
+		var err error
+		revision, err = strconv.Atoi(r)
+		if err != nil {
+			return nil, errors.BadRequestf("invalid revision %q", r)
+		}
+	}
+	t, err := s.term(owner, name, revision)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return []wireformat.Term{t}, nil
+}
+
+func (s *Server) saveTerm(req *http.Request, ownerName []string) (interface{}, error) {
+	owner, name := splitOwnerName(ownerName)
+	var request wireformat.SaveTerm
+	if err := json.NewDecoder(req.Body).Decode(&request); err != nil {
+		return nil, errors.NewBadRequest(err, "invalid request")
+	}
+	if err := request.Validate(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	t := s.addTerm(owner, name, request.Content)
+	return wireformat.TermIDResponse{TermID: t.Id}, nil
+}
+
+func (s *Server) publishTerm(owner, name, revisionStr string) (interface{}, error) {
+	revision, err := strconv.Atoi(revisionStr)
+	if err != nil || revision < 1 {
+		return nil, errors.BadRequestf("invalid revision %q", revisionStr)
+	}
+	if _, err := s.term(owner, name, revision); err != nil {
+		return nil, errors.Trace(err)
+	}
+	t := s.publish(owner, name, revision)
+	return wireformat.TermIDResponse{TermID: t.Id}, nil
+}
+
+func (s *Server) termsByOwner(owner string) []wireformat.Term {
+	terms := []wireformat.Term{}
+	for _, revisions := range s.terms {
+		for _, t := range revisions {
+			if t.Owner == owner {
+				terms = append(terms, t)
+			}
+		}
+	}
+	sort.Sort(wireformat.Terms(terms))
+	return terms
+}
+
+func (s *Server) unsignedTerms(ids []string) (interface{}, error) {
+	results := []wireformat.GetTermsResponse{}
+	for _, id := range ids {
+		termID, err := charm.ParseTerm(id)
+		if err != nil {
+			return nil, errors.NewBadRequest(err, "")
+		}
+		t, err := s.term(termID.Owner, termID.Name, termID.Revision)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if s.agreed(s.user, t) {
+			continue
+		}
+		results = append(results, wireformat.GetTermsResponse{
+			Name:      t.Name,
+			Owner:     t.Owner,
+			Title:     t.Title,
+			Revision:  t.Revision,
+			CreatedOn: time.Time(t.CreatedOn),
+			Content:   t.Content,
+		})
+	}
+	return results, nil
+}
+
+func (s *Server) saveAgreements(req *http.Request) (interface{}, error) {
+	var request []wireformat.SaveAgreement
+	if err := json.NewDecoder(req.Body).Decode(&request); err != nil {
+		return nil, errors.NewBadRequest(err, "invalid request")
+	}
+	var terms []wireformat.Term
+	for _, a := range request {
+		if a.TermRevision == 0 {
+			return nil, errors.BadRequestf("term revision not specified")
+		}
+		t, err := s.term(a.TermOwner, a.TermName, a.TermRevision)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if !t.Published {
+			return nil, errors.BadRequestf("term %q not published", t.Id)
+		}
+		terms = append(terms, t)
+	}
+	results := wireformat.SaveAgreementResponses{
+		Agreements: []wireformat.AgreementResponse{},
+	}
+	for _, t := range terms {
+		agreement := wireformat.AgreementResponse{
+			User:      s.user,
+			Owner:     t.Owner,
+			Term:      t.Name,
+			Revision:  t.Revision,
+			CreatedOn: s.now().UTC().Truncate(time.Second),
+		}
+		if !s.agreed(s.user, t) {
+			s.agreements[s.user] = append(s.agreements[s.user], agreement)
+		}
+		results.Agreements = append(results.Agreements, agreement)
+	}
+	return results, nil
+}
+
+func (s *Server) userAgreements() []wireformat.AgreementResponse {
+	return append([]wireformat.AgreementResponse{}, s.agreements[s.user]...)
+}
+
+// debugStatus returns a copy of the results of the status checks.
+func (s *Server) debugStatus() wireformat.DebugStatusResponse {
+	checks := make(map[string]wireformat.CheckResult, len(s.checks))
+	for key, check := range s.checks {
+		checks[key] = check
+	}
+	return wireformat.DebugStatusResponse{Checks: checks}
+}
+
+// addTerm adds a new revision of the specified term.
+func (s *Server) addTerm(owner, name, content string) wireformat.Term {
+	key := termKey(owner, name)
+	revision := len(s.terms[key]) + 1
+	t := wireformat.Term{
+		Id:        termID(owner, name, revision),
+		Owner:     owner,
+		Name:      name,
+		Revision:  revision,
+		CreatedOn: wireformat.TimeRFC3339(s.now().UTC().Truncate(time.Second)),
+		Published: owner == "",
+		Content:   content,
+	}
+	s.terms[key] = append(s.terms[key], t)
+	return t
+}
+
+// publish marks the specified revision of the term as published.
+func (s *Server) publish(owner, name string, revision int) wireformat.Term {
+	revisions := s.terms[termKey(owner, name)]
+	revisions[revision-1].Published = true
+	return revisions[revision-1]
+}
+
+// term returns the specified revision of the term. If revision is 0,
+// the latest revision is returned.
+func (s *Server) term(owner, name string, revision int) (wireformat.Term, error) {
+	revisions := s.terms[termKey(owner, name)]
+	if len(revisions) == 0 {
+		return wireformat.Term{}, errors.NotFoundf("term %q", termKey(owner, name))
+	}
+	if revision == 0 {
+		return revisions[len(revisions)-1], nil
+	}
+	if revision < 0 || revision > len(revisions) {
+		return wireformat.Term{}, errors.NotFoundf("term %q", termID(owner, name, revision))
+	}
+	return revisions[revision-1], nil
+}
+
+// agreed reports whether the user has agreed to the term.
+func (s *Server) agreed(user string, t wireformat.Term) bool {
+	for _, a := range s.agreements[user] {
+		if a.Owner == t.Owner && a.Term == t.Name && a.Revision == t.Revision {
+			return true
+		}
+	}
+	return false
+}
+
+func splitOwnerName(parts []string) (owner, name string) {
+	if len(parts) == 1 {
+		return "", parts[0]
+	}
+	return parts[0], parts[1]
+}
+
+func termKey(owner, name string) string {
+	if owner == "" {
+		return name
+	}
+	return owner + "/" + name
+}
+
+func termID(owner, name string, revision int) string {
+	return fmt.Sprintf("%s/%d", termKey(owner, name), revision)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status, code := http.StatusInternalServerError, "internal error"
+	switch {
+	case errors.IsNotFound(err):
+		status, code = http.StatusNotFound, "not found"
+	case errors.IsBadRequest(err):
+		status, code = http.StatusBadRequest, "bad request"
+	}
+	writeJSON(w, status, struct {
+		Code  string `json:"code"`
+		Error string `json:"error"`
+	}{
+		Code:  code,
+		Error: err.Error(),
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(data)
+}
@@ -0,0 +1,275 @@
+// Copyright 2020 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package termstest_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	stdtesting "testing"
+	"time"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/terms-client/api"
+	"github.com/juju/terms-client/api/termstest"
+	"github.com/juju/terms-client/api/wireformat"
+)
+
+func Test(t *stdtesting.T) {
+	gc.TestingT(t)
+}
+
+type serverSuite struct {
+	server *termstest.Server
+	client api.Client
+	now    time.Time
+}
+
+var _ = gc.Suite(&serverSuite{})
+
+func (s *serverSuite) SetUpTest(c *gc.C) {
+	s.now = time.Date(2016, 1, 2, 4, 8, 16, 0, time.UTC)
+	s.server = termstest.NewServer()
+	s.server.SetNow(func() time.Time { return s.now })
+	s.client = s.server.Client()
+}
+
+func (s *serverSuite) TestSaveAndGetTerm(c *gc.C) {
+	ctx := context.Background()
+	id, err := s.client.SaveTerm(ctx, "owner", "test-term", "first")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(id, gc.Equals, "owner/test-term/1")
+	id, err = s.client.SaveTerm(ctx, "owner", "test-term", "second")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(id, gc.Equals, "owner/test-term/2")
+
+	t, err := s.client.GetTerm(ctx, "owner", "test-term", 1)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(t, jc.DeepEquals, &wireformat.Term{
+		Id:        "owner/test-term/1",
+		Owner:     "owner",
+		Name:      "test-term",
+		Revision:  1,
+		CreatedOn: wireformat.TimeRFC3339(s.now),
+		Content:   "first",
+	})
+	t, err = s.client.GetTerm(ctx, "owner", "test-term", 0)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(t.Revision, gc.Equals, 2)
+	c.Assert(t.Content, gc.Equals, "second")
+
+	_, err = s.client.GetTerm(ctx, "owner", "test-term", 3)
+	c.Assert(err, gc.ErrorMatches, `term "owner/test-term/3" not found`)
+	c.Assert(errors.IsNotFound(err), jc.IsTrue)
+	_, err = s.client.GetTerm(ctx, "", "test-term", 0)
+	c.Assert(errors.IsNotFound(err), jc.IsTrue)
+}
+
+func (s *serverSuite) TestListTermRevisions(c *gc.C) {
+	s.server.AddTerm("owner", "test-term", "first", true)
+	s.server.AddTerm("owner", "test-term", "second", false)
+	s.server.AddTerm("owner", "other-term", "other", false)
+	terms, err := s.client.ListTermRevisions(context.Background(), "owner", "test-term")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(terms, jc.DeepEquals, []wireformat.Term{{
+		Id:        "owner/test-term/1",
+		Owner:     "owner",
+		Name:      "test-term",
+		Revision:  1,
+		CreatedOn: wireformat.TimeRFC3339(s.now),
+		Published: true,
+		Content:   "first",
+	}, {
+		Id:        "owner/test-term/2",
+		Owner:     "owner",
+		Name:      "test-term",
+		Revision:  2,
+		CreatedOn: wireformat.TimeRFC3339(s.now),
+		Content:   "second",
+	}})
+
+	_, err = s.client.ListTermRevisions(context.Background(), "owner", "unknown-term")
+	c.Assert(errors.IsNotFound(err), jc.IsTrue)
+}
+
+func (s *serverSuite) TestSaveEmptyTerm(c *gc.C) {
+	_, err := s.client.SaveTerm(context.Background(), "owner", "test-term", "")
+	c.Assert(err, gc.ErrorMatches, "empty term content")
+	c.Assert(errors.IsBadRequest(err), jc.IsTrue)
+}
+
+func (s *serverSuite) TestPublish(c *gc.C) {
+	ctx := context.Background()
+	s.server.AddTerm("owner", "test-term", "content", false)
+	id, err := s.client.Publish(ctx, "owner", "test-term", 1)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(id, gc.Equals, "owner/test-term/1")
+	t, err := s.client.GetTerm(ctx, "owner", "test-term", 1)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(t.Published, jc.IsTrue)
+
+	_, err = s.client.Publish(ctx, "owner", "test-term", 2)
+	c.Assert(errors.IsNotFound(err), jc.IsTrue)
+
+	for _, revision := range []int{0, -1} {
+		_, err = s.client.Publish(ctx, "owner", "test-term", revision)
+		c.Assert(err, gc.ErrorMatches, fmt.Sprintf(`invalid revision "%d"`, revision))
+		c.Assert(errors.IsBadRequest(err), jc.IsTrue)
+	}
+}
+
+func (s *serverSuite) TestGetTermsByOwner(c *gc.C) {
+	s.server.AddTerm("owner", "b-term", "content", true)
+	s.server.AddTerm("owner", "a-term", "content", false)
+	s.server.AddTerm("other", "c-term", "content", true)
+	s.server.AddTerm("", "d-term", "content", false)
+
+	terms, err := s.client.GetTermsByOwner(context.Background(), "owner")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(terms, jc.DeepEquals, []wireformat.Term{{
+		Id:        "owner/a-term/1",
+		Owner:     "owner",
+		Name:      "a-term",
+		Revision:  1,
+		CreatedOn: wireformat.TimeRFC3339(s.now),
+		Content:   "content",
+	}, {
+		Id:        "owner/b-term/1",
+		Owner:     "owner",
+		Name:      "b-term",
+		Revision:  1,
+		CreatedOn: wireformat.TimeRFC3339(s.now),
+		Published: true,
+		Content:   "content",
+	}})
+
+	terms, err = s.client.GetTermsByOwner(context.Background(), "nobody")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(terms, gc.HasLen, 0)
+}
+
+func (s *serverSuite) TestAgreements(c *gc.C) {
+	ctx := context.Background()
+	s.server.AddTerm("owner", "test-term", "owned content", true)
+	s.server.AddTerm("", "other-term", "other content", false)
+	s.server.AddTerm("owner", "unpublished-term", "content", false)
+
+	unsigned, err := s.client.GetUnsignedTerms(ctx, &wireformat.CheckAgreementsRequest{
+		Terms: []string{"owner/test-term/1", "other-term/1"},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(unsigned, jc.DeepEquals, []wireformat.GetTermsResponse{{
+		Name:      "test-term",
+		Owner:     "owner",
+		Revision:  1,
+		CreatedOn: s.now,
+		Content:   "owned content",
+	}, {
+		Name:      "other-term",
+		Revision:  1,
+		CreatedOn: s.now,
+		Content:   "other content",
+	}})
+
+	response, err := s.client.SaveAgreement(ctx, &wireformat.SaveAgreements{
+		Agreements: []wireformat.SaveAgreement{{
+			TermOwner:    "owner",
+			TermName:     "test-term",
+			TermRevision: 1,
+		}},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	expected := []wireformat.AgreementResponse{{
+		User:      termstest.DefaultUser,
+		Owner:     "owner",
+		Term:      "test-term",
+		Revision:  1,
+		CreatedOn: s.now,
+	}}
+	c.Assert(response.Agreements, jc.DeepEquals, expected)
+
+	agreements, err := s.client.GetUsersAgreements(ctx)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(agreements, jc.DeepEquals, expected)
+	c.Assert(s.server.Agreements(termstest.DefaultUser), jc.DeepEquals, expected)
+
+	unsigned, err = s.client.GetUnsignedTerms(ctx, &wireformat.CheckAgreementsRequest{
+		Terms: []string{"owner/test-term/1", "other-term/1"},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(unsigned, gc.HasLen, 1)
+	c.Assert(unsigned[0].Name, gc.Equals, "other-term")
+
+	_, err = s.client.SaveAgreement(ctx, &wireformat.SaveAgreements{
+		Agreements: []wireformat.SaveAgreement{{
+			TermOwner:    "owner",
+			TermName:     "unpublished-term",
+			TermRevision: 1,
+		}},
+	})
+	c.Assert(err, gc.ErrorMatches, `failed to save agreement: term "owner/unpublished-term/1" not published`)
+	c.Assert(errors.IsBadRequest(err), jc.IsTrue)
+
+	s.server.SetUser("another-user")
+	agreements, err = s.client.GetUsersAgreements(ctx)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(agreements, gc.HasLen, 0)
+}
+
+func (s *serverSuite) TestDebugStatus(c *gc.C) {
+	ctx := context.Background()
+	status, err := s.client.DebugStatus(ctx)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(status, jc.DeepEquals, &wireformat.DebugStatusResponse{
+		Checks: map[string]wireformat.CheckResult{
+			"server_started": {
+				Name:   "Server started",
+				Value:  "Started",
+				Passed: true,
+			},
+		},
+	})
+
+	checks := map[string]wireformat.CheckResult{
+		"mongo_connected": {
+			Name:     "MongoDB is connected",
+			Value:    "Not connected",
+			Passed:   false,
+			Duration: time.Second,
+		},
+	}
+	s.server.SetChecks(checks)
+	status, err = s.client.DebugStatus(ctx)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(status, jc.DeepEquals, &wireformat.DebugStatusResponse{Checks: checks})
+}
+
+func (s *serverSuite) TestHTTPServer(c *gc.C) {
+	s.server.AddTerm("owner", "test-term", "content", true)
+	httpServer := httptest.NewServer(s.server)
+	defer httpServer.Close()
+	client, err := api.NewClient(
+		api.HTTPClient(http.DefaultClient),
+		api.ServiceURL(httpServer.URL),
+	)
+	c.Assert(err, jc.ErrorIsNil)
+	ctx := context.WithValue(context.Background(), "X-Request-ID", "test-id")
+	t, err := client.GetTerm(ctx, "owner", "test-term", 1)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(t.Id, gc.Equals, "owner/test-term/1")
+
+	_, err = client.GetTerm(ctx, "owner", "unknown", 1)
+	serviceErr, ok := api.ServiceError(err)
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(serviceErr, jc.DeepEquals, &api.Error{
+		StatusCode: http.StatusNotFound,
+		Code:       "not found",
+		Message:    `term "owner/unknown" not found`,
+		RequestID:  "test-id",
+	})
+}
@@ -34,7 +34,7 @@ type Term struct {
 	Revision  int         `json:"revision" yaml:"revision"`
 	Title     string      `json:"title,omitempty" yaml:"title,omitempty"`
 	CreatedOn TimeRFC3339 `json:"created-on,omitempty" yaml:"createdon"`
-	Published bool        `json:"published", yaml:"published"`
+	Published bool        `json:"published" yaml:"published"`
 	Content   string      `json:"content,omitempty" yaml:"content,omitempty"`
 }
 
@@ -0,0 +1,167 @@
+// Copyright 2020 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"github.com/juju/charm/v8"
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"github.com/juju/gnuflag"
+
+	"github.com/juju/terms-client/api"
+	"github.com/juju/terms-client/api/wireformat"
+)
+
+const agreeDoc = `
+agree is used to agree to one or more Terms and Conditions documents.
+The content of each document is displayed and the user is asked to
+confirm the agreement, unless the --yes flag is specified. The command
+fails if the user does not agree.
+Examples
+agree owner/enterprise-plan/1
+   displays revision 1 of the enterprise-plan Terms and Conditions
+   owned by owner and prompts for agreement.
+agree owner/enterprise-plan/1 other-plan/2 --yes
+   agrees to both Terms and Conditions without prompting.
+`
+const agreePurpose = "agree to Terms and Conditions documents"
+
+// NewAgreeCommand returns a new command that can be used
+// to agree to Terms and Conditions documents.
+func NewAgreeCommand() cmd.Command {
+	return &agreeCommand{}
+}
+
+// agreeCommand creates user agreements to the specified terms.
+type agreeCommand struct {
+	baseCommand
+
+	TermIDs []*charm.TermsId
+	Yes     bool
+}
+
+// SetFlags implements Command.SetFlags.
+func (c *agreeCommand) SetFlags(f *gnuflag.FlagSet) {
+	f.BoolVar(&c.Yes, "yes", false, "agree to terms non interactively")
+	c.baseCommand.SetFlags(f)
+}
+
+// Info implements Command.Info.
+func (c *agreeCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "agree",
+		Args:    "<term id> [<term id> ...]",
+		Purpose: agreePurpose,
+		Doc:     agreeDoc,
+	}
+}
+
+// Init reads and verifies the arguments.
+func (c *agreeCommand) Init(args []string) error {
+	if len(args) < 1 {
+		return errors.New("missing arguments")
+	}
+	c.TermIDs = nil
+	for _, arg := range args {
+		termID, err := charm.ParseTerm(arg)
+		if err != nil {
+			return errors.Annotate(err, "invalid term format")
+		}
+		if termID.Revision == 0 {
+			return errors.Errorf("must specify a term revision for %q", arg)
+		}
+		c.TermIDs = append(c.TermIDs, termID)
+	}
+	return nil
+}
+
+// Description returns a one-line description of the command.
+func (c *agreeCommand) Description() string {
+	return agreePurpose
+}
+
+// Run implements Command.Run.
+func (c *agreeCommand) Run(ctx *cmd.Context) error {
+	bakeryClient, cleanup, err := c.NewClient(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer cleanup()
+	termsClient, err := c.newTermsClient(bakeryClient)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	if !c.Yes {
+		if err := c.showTerms(ctx, termsClient); err != nil {
+			return errors.Trace(err)
+		}
+		// The answer is read without a request context so that an
+		// interrupt terminates the command as usual.
+		fmt.Fprintf(ctx.Stdout, "Do you agree to the displayed terms? (y/N): ")
+		answer, err := bufio.NewReader(ctx.Stdin).ReadString('\n')
+		if err != nil && answer == "" {
+			return errors.Annotate(err, "failed to read answer")
+		}
+		if !userAgrees(answer) {
+			return errors.New("agreement declined")
+		}
+	}
+
+	callCtx, cancel := c.newContext(ctx)
+	defer cancel()
+	agreements := make([]wireformat.SaveAgreement, len(c.TermIDs))
+	for i, termID := range c.TermIDs {
+		agreements[i] = wireformat.SaveAgreement{
+			TermOwner:    termID.Owner,
+			TermName:     termID.Name,
+			TermRevision: termID.Revision,
+		}
+	}
+	response, err := termsClient.SaveAgreement(callCtx, &wireformat.SaveAgreements{Agreements: agreements})
+	if err != nil {
+		return errors.Annotate(err, "failed to save user agreement")
+	}
+	for _, agreement := range response.Agreements {
+		fmt.Fprintf(ctx.Stdout, "Agreed to revision %d of %s\n", agreement.Revision, agreementTermName(agreement))
+	}
+	return nil
+}
+
+// showTerms displays the content of the terms to agree to.
+func (c *agreeCommand) showTerms(ctx *cmd.Context, termsClient api.Client) error {
+	callCtx, cancel := c.newContext(ctx)
+	defer cancel()
+	for _, termID := range c.TermIDs {
+		term, err := termsClient.GetTerm(callCtx, termID.Owner, termID.Name, termID.Revision)
+		if err != nil {
+			return errors.Annotatef(err, "failed to retrieve term %q", termID.String())
+		}
+		fmt.Fprintf(ctx.Stdout, "=== %s: %s ===\n%s\n========\n", termID.String(), term.Title, term.Content)
+	}
+	return nil
+}
+
+// userAgrees returns true if the answer read from the user
+// explicitly confirms the agreement.
+func userAgrees(answer string) bool {
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// agreementTermName returns the name of the term the agreement
+// was made to, prefixed with the owner of the term, if any.
+func agreementTermName(agreement wireformat.AgreementResponse) string {
+	if agreement.Owner != "" {
+		return fmt.Sprintf("%s/%s", agreement.Owner, agreement.Term)
+	}
+	return agreement.Term
+}
@@ -0,0 +1,222 @@
+// Copyright 2020 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/canonical/candid/candidclient/ussologin"
+	"github.com/juju/errors"
+	"github.com/juju/juju/juju/osenv"
+	"gopkg.in/juju/environschema.v1/form"
+	"gopkg.in/macaroon-bakery.v2/httpbakery"
+	"gopkg.in/macaroon-bakery.v2/httpbakery/agent"
+	"gopkg.in/macaroon.v2"
+
+	"github.com/juju/terms-client/config"
+)
+
+const (
+	// authEnvVar is the name of the environment variable selecting
+	// the authentication mode.
+	authEnvVar = "JUJU_TERMS_AUTH"
+
+	// agentEnvVar is the name of the environment variable holding
+	// the agent credentials, in the format of an agent file.
+	agentEnvVar = "JUJU_TERMS_AGENT"
+
+	// agentFileEnvVar is the name of the environment variable
+	// holding the path of the agent file, as used by the other
+	// macaroon-bakery based tools.
+	agentFileEnvVar = "BAKERY_AGENT_FILE"
+
+	// macaroonsEnvVar is the name of the environment variable holding
+	// pre-discharged macaroons.
+	macaroonsEnvVar = "JUJU_TERMS_MACAROONS"
+)
+
+// authMode returns the authentication mode selected by the command
+// line flags, the environment or the profile, in that order. At each
+// level, agent credentials select agent authentication unless a mode
+// is explicitly specified at that level.
+func (s *baseCommand) authMode(profile config.Profile) (config.AuthMode, error) {
+	if s.Auth != "" && s.NoBrowser && s.Auth != string(config.AuthNoBrowser) {
+		return "", errors.Errorf("cannot specify both -B and --auth %s", s.Auth)
+	}
+	mode := s.Auth
+	if mode == "" && s.NoBrowser {
+		mode = string(config.AuthNoBrowser)
+	}
+	if s.AgentFile != "" {
+		if mode != "" && mode != string(config.AuthAgent) {
+			return "", errors.Errorf("cannot specify --agent-file with %s authentication", mode)
+		}
+		return config.AuthAgent, nil
+	}
+	switch {
+	case mode != "":
+	case os.Getenv(authEnvVar) != "":
+		mode = os.Getenv(authEnvVar)
+	case os.Getenv(agentEnvVar) != "" || os.Getenv(agentFileEnvVar) != "":
+		return config.AuthAgent, nil
+	case profile.Auth != "":
+		mode = string(profile.Auth)
+	case profile.AgentFile != "":
+		return config.AuthAgent, nil
+	default:
+		return config.AuthBrowser, nil
+	}
+	return config.ParseAuthMode(mode)
+}
+
+// setUpAuth sets up the client to authenticate users with the mode
+// selected for the command.
+func (s *baseCommand) setUpAuth(client *httpbakery.Client) error {
+	switch s.auth {
+	case config.AuthAgent:
+		authInfo, err := s.agentAuthInfo()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if err := agent.SetUpAuth(client, authInfo); err != nil {
+			return errors.Annotate(err, "cannot set up agent authentication")
+		}
+	case config.AuthNoBrowser:
+		filler := &form.IOFiller{
+			In:  os.Stdin,
+			Out: os.Stdout,
+		}
+		store := ussologin.NewFileTokenStore(osenv.JujuXDGDataHomePath("store-usso-token"))
+		interactor := ussologin.NewInteractor(ussologin.StoreTokenGetter{
+			Store: store,
+			TokenGetter: ussologin.FormTokenGetter{
+				Filler: filler,
+				Name:   "juju",
+			},
+		})
+		client.AddInteractor(interactor)
+	default:
+		client.AddInteractor(httpbakery.WebBrowserInteractor{})
+	}
+	return nil
+}
+
+// agentAuthInfo returns the agent credentials specified on the command
+// line, the environment or the profile, in that order.
+func (s *baseCommand) agentAuthInfo() (*agent.AuthInfo, error) {
+	var path string
+	switch {
+	case s.AgentFile != "":
+		path = s.AgentFile
+	case os.Getenv(agentEnvVar) != "":
+		info, err := parseAgentAuthInfo([]byte(os.Getenv(agentEnvVar)))
+		if err != nil {
+			return nil, errors.Annotatef(err, "invalid agent credentials in $%s", agentEnvVar)
+		}
+		return info, nil
+	case os.Getenv(agentFileEnvVar) != "":
+		path = os.Getenv(agentFileEnvVar)
+	case s.profile.AgentFile != "":
+		path = s.profile.AgentFile
+	default:
+		return nil, errors.Errorf("agent authentication requires --agent-file, $%s, $%s or the agent-file of the profile", agentEnvVar, agentFileEnvVar)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot read agent file")
+	}
+	info, err := parseAgentAuthInfo(data)
+	if err != nil {
+		return nil, errors.Annotatef(err, "invalid agent file %q", path)
+	}
+	return info, nil
+}
+
+// parseAgentAuthInfo parses agent credentials in the JSON format of
+// agent files.
+func parseAgentAuthInfo(data []byte) (*agent.AuthInfo, error) {
+	var info agent.AuthInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if info.Key == nil {
+		return nil, errors.New("no private key found")
+	}
+	if len(info.Agents) == 0 {
+		return nil, errors.New("no agents found")
+	}
+	return &info, nil
+}
+
+// injectMacaroons adds the pre-discharged macaroons specified on the
+// command line, the environment or the profile, in that order, to the
+// cookies sent to the terms service. The macaroons are added as
+// session cookies so that they are never stored in the cookie file.
+func (s *baseCommand) injectMacaroons(client *httpbakery.Client) error {
+	var (
+		data []byte
+		err  error
+	)
+	switch {
+	case s.MacaroonsFile != "":
+		data, err = ioutil.ReadFile(s.MacaroonsFile)
+	case os.Getenv(macaroonsEnvVar) != "":
+		data = []byte(os.Getenv(macaroonsEnvVar))
+	case s.profile.MacaroonsFile != "":
+		data, err = ioutil.ReadFile(s.profile.MacaroonsFile)
+	default:
+		return nil
+	}
+	if err != nil {
+		return errors.Annotate(err, "cannot read macaroons")
+	}
+	ms, err := parseMacaroons(data)
+	if err != nil {
+		return errors.Annotate(err, "invalid macaroons")
+	}
+	u, err := url.Parse(s.ServiceURL)
+	if err != nil {
+		return errors.Annotate(err, "invalid terms service URL")
+	}
+	cookie, err := httpbakery.NewCookie(nil, ms)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	cookie.Expires = time.Time{}
+	cookie.Path = "/"
+	client.Jar.SetCookies(u, []*http.Cookie{cookie})
+	return nil
+}
+
+// parseMacaroons parses a primary macaroon followed by its discharges,
+// encoded as a JSON array, optionally base64 encoded as in macaroon
+// cookies.
+func parseMacaroons(data []byte) (macaroon.Slice, error) {
+	data = bytes.TrimSpace(data)
+	if !bytes.HasPrefix(data, []byte("[")) {
+		decoded, err := base64.StdEncoding.DecodeString(string(data))
+		if err != nil {
+			decoded, err = base64.URLEncoding.DecodeString(string(data))
+			if err != nil {
+				return nil, errors.New("neither a JSON array nor base64 encoded")
+			}
+		}
+		data = decoded
+	}
+	var ms macaroon.Slice
+	if err := json.Unmarshal(data, &ms); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if len(ms) == 0 {
+		return nil, errors.New("no macaroons found")
+	}
+	return ms, nil
+}
@@ -4,24 +4,54 @@
 package cmd_test
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+
 	gc "gopkg.in/check.v1"
+	"gopkg.in/macaroon-bakery.v2/bakery"
+	"gopkg.in/macaroon-bakery.v2/httpbakery"
+	"gopkg.in/macaroon-bakery.v2/httpbakery/agent"
+	"gopkg.in/macaroon.v2"
 
 	jujucmd "github.com/juju/cmd"
 	"github.com/juju/cmd/cmdtesting"
 	"github.com/juju/gnuflag"
 	"github.com/juju/testing"
 	jc "github.com/juju/testing/checkers"
+	"github.com/juju/utils"
 
 	"github.com/juju/terms-client/cmd"
 )
 
 type baseCommandSuite struct {
 	testing.CleanupSuite
-	caCert string
+	caCert     string
+	configFile string
 }
 
 var _ = gc.Suite(&baseCommandSuite{})
 
+func (s *baseCommandSuite) SetUpTest(c *gc.C) {
+	s.CleanupSuite.SetUpTest(c)
+	s.configFile = filepath.Join(c.MkDir(), "terms-client.yaml")
+	s.PatchValue(cmd.ConfigFile, func() string { return s.configFile })
+	for _, name := range []string{
+		"JUJU_TERMS",
+		"JUJU_TERMS_PROFILE",
+		"JUJU_COOKIEFILE",
+		"JUJU_TERMS_AUTH",
+		"JUJU_TERMS_AGENT",
+		"BAKERY_AGENT_FILE",
+		"JUJU_TERMS_MACAROONS",
+	} {
+		s.PatchEnvironment(name, "")
+	}
+}
+
 func newTestCommand() *testCommand {
 	return &testCommand{cmd.NewBaseCommand()}
 }
@@ -80,3 +110,326 @@ func (s *baseCommandSuite) TestNewClientNoHttps(c *gc.C) {
 	defer cleanup()
 	c.Assert(client.Transport, gc.IsNil)
 }
+
+const testConfig = `
+default-profile: production
+profiles:
+  production:
+    terms-url: https://terms.example.com
+  staging:
+    terms-url: https://terms.staging.example.com
+    cookie-file: /tmp/staging-cookies
+  local:
+    terms-url: http://localhost:8080
+    auth: no-browser
+`
+
+func (s *baseCommandSuite) TestProfiles(c *gc.C) {
+	tests := []struct {
+		about      string
+		config     string
+		env        map[string]string
+		args       []string
+		err        string
+		url        string
+		noBrowser  bool
+		cookieFile string
+	}{{
+		about:      "no configuration file",
+		url:        "https://api.jujucharms.com/terms",
+		cookieFile: filepath.Join(utils.Home(), ".go-cookies"),
+	}, {
+		about:      "default profile",
+		config:     testConfig,
+		url:        "https://terms.example.com",
+		cookieFile: filepath.Join(utils.Home(), ".go-cookies"),
+	}, {
+		about:      "profile selected by flag",
+		config:     testConfig,
+		env:        map[string]string{"JUJU_TERMS_PROFILE": "local"},
+		args:       []string{"--profile", "staging"},
+		url:        "https://terms.staging.example.com",
+		cookieFile: "/tmp/staging-cookies",
+	}, {
+		about:      "profile selected by environment",
+		config:     testConfig,
+		env:        map[string]string{"JUJU_TERMS_PROFILE": "local"},
+		url:        "http://localhost:8080",
+		noBrowser:  true,
+		cookieFile: filepath.Join(utils.Home(), ".go-cookies"),
+	}, {
+		about:  "environment overrides profile",
+		config: testConfig,
+		env: map[string]string{
+			"JUJU_TERMS":      "https://terms.env.example.com",
+			"JUJU_COOKIEFILE": "/tmp/env-cookies",
+		},
+		args:       []string{"--profile", "staging"},
+		url:        "https://terms.env.example.com",
+		cookieFile: "/tmp/env-cookies",
+	}, {
+		about:      "flag overrides environment",
+		config:     testConfig,
+		env:        map[string]string{"JUJU_TERMS": "https://terms.env.example.com"},
+		args:       []string{"--url", "https://terms.flag.example.com", "--profile", "staging"},
+		url:        "https://terms.flag.example.com",
+		cookieFile: "/tmp/staging-cookies",
+	}, {
+		about:  "unknown profile",
+		config: testConfig,
+		args:   []string{"--profile", "unknown"},
+		err:    `profile "unknown" \(defined profiles: local, production, staging\) not found`,
+	}, {
+		about:  "invalid configuration",
+		config: "profiles:\n  local:\n    auth: password\n",
+		err:    `invalid configuration file ".*": profile "local": auth "password" \(must be one of browser, no-browser, agent\) not valid`,
+	}}
+	for i, test := range tests {
+		c.Logf("running test %d: %s", i, test.about)
+		if test.config != "" {
+			err := ioutil.WriteFile(s.configFile, []byte(test.config), 0600)
+			c.Assert(err, jc.ErrorIsNil)
+		}
+		for name, value := range test.env {
+			s.PatchEnvironment(name, value)
+		}
+		basecmd := newTestCommand()
+		_, err := cmdtesting.RunCommand(c, basecmd, test.args...)
+		c.Assert(err, jc.ErrorIsNil)
+		_, cleanup, err := basecmd.NewClient(cmdtesting.Context(c))
+		cleanup()
+		cookieFile := basecmd.CookieFile()
+		for name := range test.env {
+			s.PatchEnvironment(name, "")
+		}
+		if test.config != "" {
+			err := os.Remove(s.configFile)
+			c.Assert(err, jc.ErrorIsNil)
+		}
+		if test.err != "" {
+			c.Assert(err, gc.ErrorMatches, test.err)
+			continue
+		}
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(basecmd.ServiceURL, gc.Equals, test.url)
+		c.Assert(basecmd.NoBrowser, gc.Equals, test.noBrowser)
+		c.Assert(cookieFile, gc.Equals, test.cookieFile)
+	}
+}
+
+func (s *baseCommandSuite) TestAgentAuth(c *gc.C) {
+	s.PatchEnvironment("JUJU_COOKIEFILE", filepath.Join(c.MkDir(), "cookies"))
+	key, err := bakery.GenerateKey()
+	c.Assert(err, jc.ErrorIsNil)
+	authInfo, err := json.Marshal(agent.AuthInfo{
+		Key: key,
+		Agents: []agent.Agent{{
+			URL:      "https://identity.example.com",
+			Username: "ci-bot",
+		}},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	dir := c.MkDir()
+	agentFile := filepath.Join(dir, "agent.json")
+	err = ioutil.WriteFile(agentFile, authInfo, 0600)
+	c.Assert(err, jc.ErrorIsNil)
+	noKeyFile := filepath.Join(dir, "no-key.json")
+	err = ioutil.WriteFile(noKeyFile, []byte(`{"agents":[{"url":"https://identity.example.com","username":"ci-bot"}]}`), 0600)
+	c.Assert(err, jc.ErrorIsNil)
+
+	tests := []struct {
+		about       string
+		config      string
+		env         map[string]string
+		args        []string
+		err         string
+		interaction string
+	}{{
+		about:       "browser by default",
+		interaction: "browser-window",
+	}, {
+		about:       "agent selected by flag",
+		args:        []string{"--auth", "agent", "--agent-file", agentFile},
+		interaction: "agent",
+	}, {
+		about:       "agent implied by agent file",
+		args:        []string{"--agent-file", agentFile},
+		interaction: "agent",
+	}, {
+		about: "agent credentials in the environment",
+		env: map[string]string{
+			"JUJU_TERMS_AUTH":  "agent",
+			"JUJU_TERMS_AGENT": string(authInfo),
+		},
+		interaction: "agent",
+	}, {
+		about: "bakery agent file",
+		env: map[string]string{
+			"JUJU_TERMS_AUTH":   "agent",
+			"BAKERY_AGENT_FILE": agentFile,
+		},
+		interaction: "agent",
+	}, {
+		about:       "agent selected by profile",
+		config:      "default-profile: ci\nprofiles:\n  ci:\n    auth: agent\n    agent-file: " + agentFile + "\n",
+		interaction: "agent",
+	}, {
+		about:       "agent implied by agent file in the profile",
+		config:      "default-profile: ci\nprofiles:\n  ci:\n    agent-file: " + agentFile + "\n",
+		interaction: "agent",
+	}, {
+		about:       "agent file flag overrides profile",
+		config:      "default-profile: ci\nprofiles:\n  ci:\n    auth: browser\n",
+		args:        []string{"--agent-file", agentFile},
+		interaction: "agent",
+	}, {
+		about:  "agent credentials in the environment override profile",
+		config: "default-profile: ci\nprofiles:\n  ci:\n    auth: browser\n",
+		env: map[string]string{
+			"BAKERY_AGENT_FILE": agentFile,
+		},
+		interaction: "agent",
+	}, {
+		about:  "auth environment variable overrides agent credentials",
+		config: "default-profile: ci\nprofiles:\n  ci:\n    auth: agent\n",
+		env: map[string]string{
+			"JUJU_TERMS_AUTH":   "no-browser",
+			"BAKERY_AGENT_FILE": agentFile,
+		},
+		interaction: "usso_oauth",
+	}, {
+		about:       "flag overrides profile",
+		config:      "default-profile: ci\nprofiles:\n  ci:\n    auth: agent\n",
+		args:        []string{"-B"},
+		interaction: "usso_oauth",
+	}, {
+		about: "no agent credentials",
+		args:  []string{"--auth", "agent"},
+		err:   `agent authentication requires --agent-file, \$JUJU_TERMS_AGENT, \$BAKERY_AGENT_FILE or the agent-file of the profile`,
+	}, {
+		about: "no private key",
+		args:  []string{"--agent-file", noKeyFile},
+		err:   `invalid agent file ".*": no private key found`,
+	}, {
+		about: "invalid agent credentials in the environment",
+		env: map[string]string{
+			"JUJU_TERMS_AUTH":  "agent",
+			"JUJU_TERMS_AGENT": "{",
+		},
+		err: `invalid agent credentials in \$JUJU_TERMS_AGENT: .*`,
+	}, {
+		about: "conflicting flags",
+		args:  []string{"-B", "--auth", "agent"},
+		err:   "cannot specify both -B and --auth agent",
+	}, {
+		about: "agent file with another mode",
+		args:  []string{"--auth", "browser", "--agent-file", agentFile},
+		err:   "cannot specify --agent-file with browser authentication",
+	}, {
+		about: "agent file with -B",
+		args:  []string{"-B", "--agent-file", agentFile},
+		err:   "cannot specify --agent-file with no-browser authentication",
+	}, {
+		about: "unknown mode",
+		args:  []string{"--auth", "password"},
+		err:   `auth "password" \(must be one of browser, no-browser, agent\) not valid`,
+	}}
+	for i, test := range tests {
+		c.Logf("running test %d: %s", i, test.about)
+		if test.config != "" {
+			err := ioutil.WriteFile(s.configFile, []byte(test.config), 0600)
+			c.Assert(err, jc.ErrorIsNil)
+		}
+		for name, value := range test.env {
+			s.PatchEnvironment(name, value)
+		}
+		basecmd := newTestCommand()
+		_, err := cmdtesting.RunCommand(c, basecmd, test.args...)
+		c.Assert(err, jc.ErrorIsNil)
+		client, cleanup, err := basecmd.NewClient(cmdtesting.Context(c))
+		cleanup()
+		for name := range test.env {
+			s.PatchEnvironment(name, "")
+		}
+		if test.config != "" {
+			err := os.Remove(s.configFile)
+			c.Assert(err, jc.ErrorIsNil)
+		}
+		if test.err != "" {
+			c.Assert(err, gc.ErrorMatches, test.err)
+			continue
+		}
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(client.InteractionMethods, gc.HasLen, 1)
+		c.Assert(client.InteractionMethods[0].Kind(), gc.Equals, test.interaction)
+		if test.interaction == "agent" {
+			c.Assert(client.Key, jc.DeepEquals, key)
+		}
+	}
+}
+
+func (s *baseCommandSuite) TestMacaroons(c *gc.C) {
+	cookieFile := filepath.Join(c.MkDir(), "cookies")
+	s.PatchEnvironment("JUJU_COOKIEFILE", cookieFile)
+	m, err := macaroon.New([]byte("root key"), []byte("test-id"), "terms", macaroon.LatestVersion)
+	c.Assert(err, jc.ErrorIsNil)
+	data, err := json.Marshal(macaroon.Slice{m})
+	c.Assert(err, jc.ErrorIsNil)
+	dir := c.MkDir()
+	macaroonsFile := filepath.Join(dir, "macaroons.json")
+	err = ioutil.WriteFile(macaroonsFile, data, 0600)
+	c.Assert(err, jc.ErrorIsNil)
+	emptyFile := filepath.Join(dir, "empty.json")
+	err = ioutil.WriteFile(emptyFile, []byte("[]"), 0600)
+	c.Assert(err, jc.ErrorIsNil)
+
+	tests := []struct {
+		about string
+		env   string
+		args  []string
+		err   string
+	}{{
+		about: "file",
+		args:  []string{"--macaroons", macaroonsFile},
+	}, {
+		about: "base64 encoded in the environment",
+		env:   base64.StdEncoding.EncodeToString(data),
+	}, {
+		about: "flag overrides environment",
+		env:   "invalid",
+		args:  []string{"--macaroons", macaroonsFile},
+	}, {
+		about: "no macaroons",
+		args:  []string{"--macaroons", emptyFile},
+		err:   "invalid macaroons: no macaroons found",
+	}, {
+		about: "invalid encoding",
+		env:   "invalid!",
+		err:   "invalid macaroons: neither a JSON array nor base64 encoded",
+	}}
+	for i, test := range tests {
+		c.Logf("running test %d: %s", i, test.about)
+		s.PatchEnvironment("JUJU_TERMS_MACAROONS", test.env)
+		basecmd := newTestCommand()
+		args := append([]string{"--url", "https://terms.example.com/terms"}, test.args...)
+		_, err := cmdtesting.RunCommand(c, basecmd, args...)
+		c.Assert(err, jc.ErrorIsNil)
+		client, cleanup, err := basecmd.NewClient(cmdtesting.Context(c))
+		if test.err != "" {
+			c.Assert(err, gc.ErrorMatches, test.err)
+			continue
+		}
+		c.Assert(err, jc.ErrorIsNil)
+		u, err := url.Parse("https://terms.example.com/terms/v1/terms/owner/name")
+		c.Assert(err, jc.ErrorIsNil)
+		mss := httpbakery.MacaroonsForURL(client.Jar, u)
+		c.Assert(mss, gc.HasLen, 1)
+		c.Assert(mss[0], gc.HasLen, 1)
+		c.Assert(mss[0][0].Id(), jc.DeepEquals, []byte("test-id"))
+		cleanup()
+		// The macaroons are never stored in the cookie file.
+		stored, err := ioutil.ReadFile(cookieFile)
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(string(stored), gc.Not(jc.Contains), "macaroon-")
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2020 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+
+	"github.com/juju/charm/v8"
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"github.com/juju/gnuflag"
+
+	"github.com/juju/terms-client/api"
+	"github.com/juju/terms-client/api/wireformat"
+)
+
+const checkTermsDoc = `
+check-terms is used to check whether the user has agreed to the
+specified Terms and Conditions documents. The terms may be given as
+arguments or read from the terms declared in a charm's metadata.yaml.
+The command lists the terms that have not been agreed to, as a table
+when the output is a terminal and in YAML format otherwise, and fails
+if there are any.
+Examples
+check-terms owner/enterprise-plan/1 other-plan/2
+   checks whether the user has agreed to both terms.
+check-terms --format tabular --columns term,title,created owner/enterprise-plan/1
+   lists the terms not agreed to as a table.
+check-terms --charm ./my-charm
+   checks whether the user has agreed to all terms required by the
+   charm in the ./my-charm directory.
+check-terms --bundle agreements.json owner/enterprise-plan/1
+   checks the agreements recorded in a bundle written by
+   export-agreements, without contacting the terms service.
+`
+const checkTermsPurpose = "checks the user has agreed to the specified terms"
+
+// NewCheckTermsCommand returns a new command that can be used
+// to check for unsigned Terms and Conditions documents.
+func NewCheckTermsCommand() cmd.Command {
+	return &checkTermsCommand{
+		table: newUnsignedTermTable("term", "title"),
+	}
+}
+
+type checkTermsCommand struct {
+	baseCommand
+	out   cmd.Output
+	table *table
+
+	TermIDs    []string
+	CharmDir   string
+	BundleFile string
+}
+
+// SetFlags implements Command.SetFlags.
+func (c *checkTermsCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, defaultFormat("yaml"), c.table.formatters())
+	c.table.SetFlags(f)
+	f.StringVar(&c.CharmDir, "charm", "", "directory of the charm whose terms are checked")
+	f.StringVar(&c.BundleFile, "bundle", "", "agreement bundle to check instead of the terms service")
+	c.baseCommand.SetFlags(f)
+}
+
+// Info implements Command.Info.
+func (c *checkTermsCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "check-terms",
+		Args:    "[<term id> ...]",
+		Purpose: checkTermsPurpose,
+		Doc:     checkTermsDoc,
+	}
+}
+
+// Init reads and verifies the arguments.
+func (c *checkTermsCommand) Init(args []string) error {
+	if len(args) == 0 && c.CharmDir == "" {
+		return errors.New("missing arguments")
+	}
+	for _, arg := range args {
+		if _, err := charm.ParseTerm(arg); err != nil {
+			return errors.Annotate(err, "invalid term format")
+		}
+	}
+	c.TermIDs = args
+	return nil
+}
+
+// Description returns a one-line description of the command.
+func (c *checkTermsCommand) Description() string {
+	return checkTermsPurpose
+}
+
+// Run implements Command.Run.
+func (c *checkTermsCommand) Run(ctx *cmd.Context) error {
+	termIDs := c.TermIDs
+	if c.CharmDir != "" {
+		charmTerms, err := readCharmTerms(ctx.AbsPath(c.CharmDir))
+		if err != nil {
+			return errors.Trace(err)
+		}
+		termIDs = append(termIDs, charmTerms...)
+	}
+	if len(termIDs) == 0 {
+		ctx.Infof("No terms to check.")
+		return nil
+	}
+
+	callCtx, cancel := c.newContext(ctx)
+	defer cancel()
+	termsClient, cleanup, err := c.termsClient(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer cleanup()
+
+	unsigned, err := termsClient.GetUnsignedTerms(callCtx, &wireformat.CheckAgreementsRequest{
+		Terms: termIDs,
+	})
+	if err != nil {
+		return errors.Annotate(err, "failed to check agreements")
+	}
+	if len(unsigned) == 0 {
+		ctx.Infof("All terms have been agreed to.")
+		return nil
+	}
+	err = c.out.Write(ctx, unsigned)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Errorf("%d term(s) not agreed to", len(unsigned))
+}
+
+// termsClient returns the client used to check the agreements: either
+// a client of the terms service or one reading the agreement bundle.
+func (c *checkTermsCommand) termsClient(ctx *cmd.Context) (api.Client, func(), error) {
+	if c.BundleFile != "" {
+		path := ctx.AbsPath(c.BundleFile)
+		data, err := readFile(path)
+		if err != nil {
+			return nil, nil, errors.Annotatef(err, "could not read contents of %q", path)
+		}
+		bundle, err := api.ReadBundle(bytes.NewReader(data))
+		if err != nil {
+			return nil, nil, errors.Annotatef(err, "cannot read %q", path)
+		}
+		return api.NewBundleClient(bundle), func() {}, nil
+	}
+	bakeryClient, cleanup, err := c.NewClient(ctx)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	termsClient, err := c.newTermsClient(bakeryClient)
+	if err != nil {
+		cleanup()
+		return nil, nil, errors.Trace(err)
+	}
+	return termsClient, cleanup, nil
+}
+
+// readCharmTerms returns the terms declared in the metadata.yaml
+// of the charm in the specified directory.
+func readCharmTerms(charmDir string) ([]string, error) {
+	metadataPath := filepath.Join(charmDir, "metadata.yaml")
+	data, err := readFile(metadataPath)
+	if err != nil {
+		return nil, errors.Annotatef(err, "could not read contents of %q", metadataPath)
+	}
+	meta, err := charm.ReadMeta(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Annotatef(err, "invalid charm metadata %q", metadataPath)
+	}
+	return meta.Terms, nil
+}
@@ -4,25 +4,41 @@
 package cmd
 
 import (
+	"context"
 	"io/ioutil"
 	"os"
 	"path"
+	"time"
 
-	"github.com/canonical/candid/candidclient/ussologin"
 	"github.com/juju/cmd"
 	"github.com/juju/errors"
 	"github.com/juju/gnuflag"
 	"github.com/juju/juju/juju/osenv"
 	cookiejar "github.com/juju/persistent-cookiejar"
 	"github.com/juju/utils"
-	"gopkg.in/juju/environschema.v1/form"
 	"gopkg.in/macaroon-bakery.v2/httpbakery"
 
 	"github.com/juju/terms-client/api"
+	"github.com/juju/terms-client/config"
 )
 
 var (
+	clientNew = func(options ...api.ClientOption) (api.Client, error) {
+		return api.NewClient(options...)
+	}
 	readFile = ioutil.ReadFile
+
+	// termsCacheDir returns the directory holding the local cache
+	// of terms.
+	termsCacheDir = func() string {
+		return osenv.JujuXDGDataHomePath("terms-cache")
+	}
+
+	// configFile returns the path of the configuration file holding
+	// the profiles.
+	configFile = func() string {
+		return osenv.JujuXDGDataHomePath("terms-client.yaml")
+	}
 )
 
 type baseCommand struct {
@@ -30,9 +46,106 @@ type baseCommand struct {
 
 	ServiceURL string
 
+	// ProfileName holds the name of the profile selected on the
+	// command line.
+	ProfileName string
+
 	// NoBrowser specifies that web-browser-based auth should
 	// not be used when authenticating.
 	NoBrowser bool
+
+	// Auth holds the authentication mode selected on the command
+	// line.
+	Auth string
+
+	// AgentFile holds the path of the file holding the agent
+	// credentials used with agent authentication.
+	AgentFile string
+
+	// MacaroonsFile holds the path of the file holding the
+	// pre-discharged macaroons sent to the terms service.
+	MacaroonsFile string
+
+	// NoCache specifies that terms must always be fetched from the
+	// terms service instead of the local cache.
+	NoCache bool
+
+	// Timeout holds the maximum duration of the command's requests
+	// to the remote services. Zero means no timeout.
+	Timeout time.Duration
+
+	// profile holds the settings of the selected profile, once
+	// configured.
+	profile config.Profile
+
+	// auth holds the selected authentication mode, once configured.
+	auth config.AuthMode
+
+	// uncached specifies that the command never reads terms from the
+	// local cache, as for the commands for which the latest revision
+	// of a term determines the outcome, such as pushing or releasing
+	// terms. Such commands do not define the --no-cache flag.
+	uncached bool
+}
+
+// configure reads the settings of the selected profile and applies
+// them to the command. Settings specified by command line flags take
+// precedence over the environment, which takes precedence over the
+// profile.
+func (s *baseCommand) configure() error {
+	cfg, err := config.Read(configFile())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	profile, err := cfg.Profile(firstNonEmpty(s.ProfileName, os.Getenv(config.ProfileEnvVar)))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, path := range []*string{&profile.CookieFile, &profile.AgentFile, &profile.MacaroonsFile} {
+		if *path == "" {
+			continue
+		}
+		*path, err = utils.NormalizePath(*path)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	s.profile = profile
+	s.ServiceURL = firstNonEmpty(s.ServiceURL, os.Getenv("JUJU_TERMS"), profile.TermsURL, api.BaseURL())
+	s.auth, err = s.authMode(profile)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	s.NoBrowser = s.auth == config.AuthNoBrowser
+	return nil
+}
+
+// newContext returns a context for the command's requests that is
+// canceled when the command is interrupted or its timeout expires. The
+// returned function releases the associated resources and must be
+// called when the requests are done.
+func (s *baseCommand) newContext(ctx *cmd.Context) (context.Context, context.CancelFunc) {
+	callCtx, cancelTimeout := context.Background(), context.CancelFunc(func() {})
+	if s.Timeout > 0 {
+		callCtx, cancelTimeout = context.WithTimeout(callCtx, s.Timeout)
+	}
+	callCtx, cancel := context.WithCancel(callCtx)
+	interrupted := make(chan os.Signal, 1)
+	ctx.InterruptNotify(interrupted)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-interrupted:
+			cancel()
+		case <-done:
+		}
+	}()
+	return callCtx, func() {
+		ctx.StopInterruptNotify(interrupted)
+		close(done)
+		cancel()
+		cancelTimeout()
+	}
 }
 
 // NewClient returns a new http bakery client for terms commands
@@ -43,38 +156,69 @@ func (s *baseCommand) NewClient(ctx *cmd.Context) (*httpbakery.Client, func(), e
 		return nil, func() {}, errors.Errorf("cannot determine juju data home, required environment variables are not set")
 	}
 	osenv.SetJujuXDGDataHome(jujuXDGDataHome)
+	if err := s.configure(); err != nil {
+		return nil, func() {}, errors.Trace(err)
+	}
 	client := httpbakery.NewClient()
-	if s.NoBrowser {
-		filler := &form.IOFiller{
-			In:  os.Stdin,
-			Out: os.Stdout,
-		}
-		store := ussologin.NewFileTokenStore(osenv.JujuXDGDataHomePath("store-usso-token"))
-		interactor := ussologin.NewInteractor(ussologin.StoreTokenGetter{
-			Store: store,
-			TokenGetter: ussologin.FormTokenGetter{
-				Filler: filler,
-				Name:   "juju",
-			},
-		})
-		client.AddInteractor(interactor)
-	} else {
-		client.AddInteractor(httpbakery.WebBrowserInteractor{})
+	if err := s.setUpAuth(client); err != nil {
+		return nil, func() {}, errors.Trace(err)
 	}
+	cleanup := func() {}
 	if jar, err := cookiejar.New(&cookiejar.Options{
-		Filename: cookieFile(),
+		Filename: cookieFile(s.profile),
 	}); err == nil {
 		client.Jar = jar
-		return client, func() {
+		cleanup = func() {
 			err := jar.Save()
 			if err != nil {
 				ctx.Warningf("failed to save cookie jar: %v", err)
 			}
-		}, nil
+		}
 	} else {
 		ctx.Warningf("failed to create cookie jar")
-		return client, func() {}, nil
 	}
+	if err := s.injectMacaroons(client); err != nil {
+		cleanup()
+		return nil, func() {}, errors.Trace(err)
+	}
+	return client, cleanup, nil
+}
+
+// newTermsClient returns a terms service client that uses the given
+// bakery client and the service URL specified for the command. Terms
+// are fetched through the local cache unless --no-cache is specified
+// or the command is uncached. Uncached commands still discard the
+// cached latest revision of the terms they save or publish so that
+// other commands see the change.
+func (s *baseCommand) newTermsClient(bakeryClient *httpbakery.Client) (api.Client, error) {
+	client, err := s.newServiceClient(bakeryClient)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	switch {
+	case s.uncached:
+		return api.NewCacheInvalidatingClient(client, termsCacheDir(), s.ServiceURL), nil
+	case s.NoCache:
+		return client, nil
+	}
+	return api.NewCachingClient(client, termsCacheDir(), s.ServiceURL, api.DefaultCacheTTL), nil
+}
+
+// newServiceClient returns a terms service client that uses the given
+// bakery client and the service URL specified for the command.
+func (s *baseCommand) newServiceClient(bakeryClient *httpbakery.Client) (api.Client, error) {
+	options := []api.ClientOption{
+		api.HTTPClient(bakeryClient),
+		api.Retry(api.DefaultRetryPolicy),
+	}
+	if s.ServiceURL != "" {
+		options = append(options, api.ServiceURL(s.ServiceURL))
+	}
+	client, err := clientNew(options...)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return client, nil
 }
 
 // newBaseCommand creates a new baseCommand with the default service
@@ -89,15 +233,30 @@ func newBaseCommand() *baseCommand {
 func (c *baseCommand) SetFlags(f *gnuflag.FlagSet) {
 	f.BoolVar(&c.NoBrowser, "B", false, "Do not use web browser for authentication")
 	f.BoolVar(&c.NoBrowser, "no-browser-login", false, "")
-	f.StringVar(&c.ServiceURL, "url", api.BaseURL(), "host and port of the terms service")
+	f.StringVar(&c.ServiceURL, "url", "", "host and port of the terms service")
+	f.StringVar(&c.ProfileName, "profile", "", "name of the configuration profile to use")
+	f.StringVar(&c.Auth, "auth", "", "authentication mode: browser, no-browser or agent")
+	f.StringVar(&c.AgentFile, "agent-file", "", "file holding the agent credentials used with agent authentication")
+	f.StringVar(&c.MacaroonsFile, "macaroons", "", "file holding pre-discharged macaroons sent to the terms service")
+	if !c.uncached {
+		f.BoolVar(&c.NoCache, "no-cache", false, "do not use the local cache of terms")
+	}
+	f.DurationVar(&c.Timeout, "timeout", 0, "maximum time to wait for the remote services (e.g. 30s); 0 means no timeout")
 }
 
 // cookieFile returns the path to the cookie used to store authorization
 // macaroons. The returned value can be overridden by setting the
-// JUJU_COOKIEFILE environment variable.
-func cookieFile() string {
-	if file := os.Getenv("JUJU_COOKIEFILE"); file != "" {
-		return file
+// JUJU_COOKIEFILE environment variable or the cookie file of the profile.
+func cookieFile(profile config.Profile) string {
+	return firstNonEmpty(os.Getenv("JUJU_COOKIEFILE"), profile.CookieFile, path.Join(utils.Home(), ".go-cookies"))
+}
+
+// firstNonEmpty returns the first of the values that is not empty.
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
 	}
-	return path.Join(utils.Home(), ".go-cookies")
+	return ""
 }
@@ -6,9 +6,19 @@ package cmd_test
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	jujucmd "github.com/juju/cmd"
 	"github.com/juju/cmd/cmdtesting"
 	"github.com/juju/errors"
 	jujutesting "github.com/juju/testing"
@@ -17,6 +27,7 @@ import (
 	"gopkg.in/macaroon-bakery.v2/httpbakery"
 
 	"github.com/juju/terms-client/api"
+	"github.com/juju/terms-client/api/termstest"
 	"github.com/juju/terms-client/api/wireformat"
 	"github.com/juju/terms-client/cmd"
 )
@@ -30,12 +41,20 @@ var _ = gc.Suite(&commandSuite{})
 var testTermsAndConditions = "Test Terms and Conditions"
 
 type commandSuite struct {
-	client    *mockClient
-	idmClient *mockIDMClient
-	cleanup   func()
+	jujutesting.CleanupSuite
+
+	client     *mockClient
+	idmClient  *mockIDMClient
+	cleanup    func()
+	configFile string
 }
 
 func (s *commandSuite) SetUpTest(c *gc.C) {
+	s.CleanupSuite.SetUpTest(c)
+	cacheDir := c.MkDir()
+	s.PatchValue(cmd.TermsCacheDir, func() string { return cacheDir })
+	s.configFile = filepath.Join(c.MkDir(), "terms-client.yaml")
+	s.PatchValue(cmd.ConfigFile, func() string { return s.configFile })
 	s.client = &mockClient{}
 	s.idmClient = &mockIDMClient{
 		username: "test-user",
@@ -60,31 +79,85 @@ func (s *commandSuite) SetUpTest(c *gc.C) {
 		c2()
 		c3()
 	}
+	s.PatchValue(cmd.IsTerminal, func(*os.File) bool { return false })
 }
 
 func (s *commandSuite) TearDownTest(c *gc.C) {
 	s.cleanup()
+	s.CleanupSuite.TearDownTest(c)
 }
 
 func (s *commandSuite) TestPushTerm(c *gc.C) {
 	tests := []struct {
-		about   string
-		args    []string
-		err     string
-		stdout  string
-		apiCall []interface{}
+		about    string
+		args     []string
+		terms    []wireformat.Term
+		err      string
+		stdout   string
+		stderr   string
+		apiCalls []jujutesting.StubCall
 	}{{
 		about: "everything works",
 		args:  []string{"test.txt", "test-term", "--format", "json"},
 		stdout: `"test-term/1"
 `,
-		apiCall: []interface{}{"", "test-term", testTermsAndConditions},
+		apiCalls: []jujutesting.StubCall{
+			{FuncName: "GetTerm", Args: []interface{}{"", "test-term", 0}},
+			{FuncName: "SaveTerm", Args: []interface{}{"", "test-term", testTermsAndConditions}},
+		},
 	}, {
 		about: "everything works - with owner",
 		args:  []string{"test.txt", "test-owner/test-term", "--format", "json"},
 		stdout: `"test-owner/test-term/1"
 `,
-		apiCall: []interface{}{"test-owner", "test-term", testTermsAndConditions},
+		apiCalls: []jujutesting.StubCall{
+			{FuncName: "GetTerm", Args: []interface{}{"test-owner", "test-term", 0}},
+			{FuncName: "SaveTerm", Args: []interface{}{"test-owner", "test-term", testTermsAndConditions}},
+		},
+	}, {
+		about: "changed content",
+		args:  []string{"test.txt", "test-owner/test-term"},
+		terms: []wireformat.Term{{
+			Owner:    "test-owner",
+			Name:     "test-term",
+			Revision: 2,
+			Content:  "Old Terms and Conditions",
+		}},
+		stdout: `test-owner/test-term/1
+`,
+		apiCalls: []jujutesting.StubCall{
+			{FuncName: "GetTerm", Args: []interface{}{"test-owner", "test-term", 0}},
+			{FuncName: "SaveTerm", Args: []interface{}{"test-owner", "test-term", testTermsAndConditions}},
+		},
+	}, {
+		about: "unchanged content",
+		args:  []string{"test.txt", "test-owner/test-term"},
+		terms: []wireformat.Term{{
+			Owner:    "test-owner",
+			Name:     "test-term",
+			Revision: 2,
+			Content:  testTermsAndConditions,
+		}},
+		stdout: `test-owner/test-term/2
+`,
+		stderr: "Content unchanged since test-owner/test-term/2, not pushing a new revision.\n",
+		apiCalls: []jujutesting.StubCall{
+			{FuncName: "GetTerm", Args: []interface{}{"test-owner", "test-term", 0}},
+		},
+	}, {
+		about: "force unchanged content",
+		args:  []string{"test.txt", "test-owner/test-term", "--force"},
+		terms: []wireformat.Term{{
+			Owner:    "test-owner",
+			Name:     "test-term",
+			Revision: 2,
+			Content:  testTermsAndConditions,
+		}},
+		stdout: `test-owner/test-term/1
+`,
+		apiCalls: []jujutesting.StubCall{
+			{FuncName: "SaveTerm", Args: []interface{}{"test-owner", "test-term", testTermsAndConditions}},
+		},
 	}, {
 		about: "invalid termid",
 		args:  []string{"test.txt", "!!!!!!", "--format", "json"},
@@ -98,17 +171,20 @@ func (s *commandSuite) TestPushTerm(c *gc.C) {
 		args:  []string{"test.txt", "cs:test-term", "--format", "json"},
 		err:   "can't specify a tenant with a new term",
 	}, {
-		about: "unknown args",
-		args:  []string{"test.txt", "test-term", "unknown", "args", "--format", "json"},
-		err:   "unknown arguments: unknown,args",
+		about:  "unknown args",
+		args:   []string{"test.txt", "test-term", "unknown", "args", "--format", "json"},
+		err:    "unknown arguments: unknown,args",
+		stderr: "ERROR unknown arguments: unknown,args\n",
 	}, {
-		about: "missing args",
-		args:  []string{"test-term", "--format", "json"},
-		err:   "missing arguments",
+		about:  "missing args",
+		args:   []string{"test-term", "--format", "json"},
+		err:    "missing arguments",
+		stderr: "ERROR missing arguments\n",
 	},
 	}
 	for i, test := range tests {
 		s.client.ResetCalls()
+		s.client.setTerms(test.terms)
 		c.Logf("running test %d: %s", i, test.about)
 		ctx, err := cmdtesting.RunCommand(c, cmd.NewPushTermCommand(), test.args...)
 		if test.err != "" {
@@ -118,9 +194,10 @@ func (s *commandSuite) TestPushTerm(c *gc.C) {
 		}
 		if ctx != nil {
 			c.Assert(cmdtesting.Stdout(ctx), gc.Equals, test.stdout)
+			c.Assert(cmdtesting.Stderr(ctx), gc.Equals, test.stderr)
 		}
-		if len(test.apiCall) > 0 {
-			s.client.CheckCall(c, 0, "SaveTerm", test.apiCall...)
+		if len(test.apiCalls) > 0 {
+			s.client.CheckCalls(c, test.apiCalls)
 		}
 	}
 }
@@ -310,11 +387,20 @@ func (s *commandSuite) TestPublishTerm(c *gc.C) {
 
 func (s *commandSuite) TestListTerms(c *gc.C) {
 	s.client.setTerms([]wireformat.Term{{
-		Id:       "test-user/test-term/1",
-		Owner:    "test-user",
-		Name:     "test-term",
-		Revision: 1,
-		Content:  testTermsAndConditions,
+		Id:        "test-user/test-term/1",
+		Owner:     "test-user",
+		Name:      "test-term",
+		Revision:  1,
+		CreatedOn: wireformat.TimeRFC3339(time.Date(2016, 1, 2, 4, 8, 16, 0, time.UTC)),
+		Published: true,
+		Content:   testTermsAndConditions,
+	}, {
+		Id:        "test-user/test-term/2",
+		Owner:     "test-user",
+		Name:      "test-term",
+		Revision:  2,
+		CreatedOn: wireformat.TimeRFC3339(time.Date(2017, 1, 2, 4, 8, 16, 0, time.UTC)),
+		Content:   testTermsAndConditions,
 	}})
 	tests := []struct {
 		about            string
@@ -327,7 +413,19 @@ func (s *commandSuite) TestListTerms(c *gc.C) {
 		about:            "everything works",
 		declaredUsername: "test-user",
 		args:             []string{},
-		stdout: `- test-user/test-term/1
+		stdout: `test-user:
+- id: test-user/test-term/1
+  owner: test-user
+  name: test-term
+  revision: 1
+  createdon: "2016-01-02T04:08:16Z"
+  published: true
+- id: test-user/test-term/2
+  owner: test-user
+  name: test-term
+  revision: 2
+  createdon: "2017-01-02T04:08:16Z"
+  published: false
 `,
 		apiCalls: []jujutesting.StubCall{
 			{FuncName: "GetTermsByOwner", Args: []interface{}{"test-user"}},
@@ -340,16 +438,57 @@ func (s *commandSuite) TestListTerms(c *gc.C) {
 	}, {
 		about:            "test private groups",
 		declaredUsername: "test-user",
-		args:             []string{"--groups", "private-group1, private-group2"},
-		stdout: `- test-user/test-term/1
-- test-user/test-term/1
-- test-user/test-term/1
+		args:             []string{"--groups", "private-group1, private-group2", "--published", "--format", "json"},
+		stdout: `{"private-group1":[{"id":"test-user/test-term/1","owner":"test-user","name":"test-term","revision":1,"created-on":"2016-01-02T04:08:16Z","published":true}],"private-group2":[{"id":"test-user/test-term/1","owner":"test-user","name":"test-term","revision":1,"created-on":"2016-01-02T04:08:16Z","published":true}],"test-user":[{"id":"test-user/test-term/1","owner":"test-user","name":"test-term","revision":1,"created-on":"2016-01-02T04:08:16Z","published":true}]}
 `,
 		apiCalls: []jujutesting.StubCall{
 			{FuncName: "GetTermsByOwner", Args: []interface{}{"private-group1"}},
 			{FuncName: "GetTermsByOwner", Args: []interface{}{"private-group2"}},
 			{FuncName: "GetTermsByOwner", Args: []interface{}{"test-user"}},
 		},
+	}, {
+		about:            "owner, unpublished and content",
+		declaredUsername: "test-unknown-user",
+		args:             []string{"--owner", "test-owner", "--unpublished", "--content"},
+		stdout: `test-owner:
+- id: test-user/test-term/2
+  owner: test-user
+  name: test-term
+  revision: 2
+  createdon: "2017-01-02T04:08:16Z"
+  published: false
+  content: Test Terms and Conditions
+`,
+		apiCalls: []jujutesting.StubCall{
+			{FuncName: "GetTermsByOwner", Args: []interface{}{"test-owner"}},
+		},
+	}, {
+		about:            "since",
+		declaredUsername: "test-user",
+		args:             []string{"--since", "2016-06-01", "--format", "tabular", "--columns", "id"},
+		stdout: `ID
+test-user/test-term/2
+`,
+		apiCalls: []jujutesting.StubCall{
+			{FuncName: "GetTermsByOwner", Args: []interface{}{"test-user"}},
+		},
+	}, {
+		about:            "since RFC3339",
+		declaredUsername: "test-user",
+		args:             []string{"--since", "2017-01-02T04:08:17Z"},
+		stdout: `{}
+`,
+		apiCalls: []jujutesting.StubCall{
+			{FuncName: "GetTermsByOwner", Args: []interface{}{"test-user"}},
+		},
+	}, {
+		about: "invalid since",
+		args:  []string{"--since", "yesterday"},
+		err:   `invalid date "yesterday", expected YYYY-MM-DD or RFC3339`,
+	}, {
+		about: "published and unpublished",
+		args:  []string{"--published", "--unpublished"},
+		err:   `cannot specify both --published and --unpublished`,
 	}}
 	for i, test := range tests {
 		s.client.ResetCalls()
@@ -365,89 +504,1716 @@ func (s *commandSuite) TestListTerms(c *gc.C) {
 			c.Assert(cmdtesting.Stdout(ctx), gc.Equals, test.stdout)
 		}
 		if len(test.apiCalls) > 0 {
-			s.client.CheckCalls(c, test.apiCalls)
+			c.Assert(sortedCalls(s.client.Calls()), jc.DeepEquals, test.apiCalls)
 		}
 	}
 }
 
-type mockClient struct {
-	api.Client
-	jujutesting.Stub
-
-	lock          sync.Mutex
-	user          string
-	terms         []wireformat.Term
-	unsignedTerms []wireformat.Term
+func (s *commandSuite) TestListTermsParallel(c *gc.C) {
+	s.client.setTerms([]wireformat.Term{{
+		Id:        "test-user/test-term/1",
+		Owner:     "test-user",
+		Name:      "test-term",
+		Revision:  1,
+		CreatedOn: wireformat.TimeRFC3339(time.Date(2016, 1, 2, 4, 8, 16, 0, time.UTC)),
+		Published: true,
+	}})
+	s.client.delay = 10 * time.Millisecond
+	tests := []struct {
+		about       string
+		args        []string
+		ownerErrs   map[string]error
+		err         string
+		stdout      string
+		stderr      string
+		maxInFlight int
+	}{{
+		about:       "bounded parallelism",
+		args:        []string{"--owner", "g5,g4,g3,g2,g1,g3", "--parallel", "2", "--format", "tabular", "--columns", "owner"},
+		stdout:      "OWNER\ntest-user\ntest-user\ntest-user\ntest-user\ntest-user\n",
+		maxInFlight: 2,
+	}, {
+		about:       "sequential",
+		args:        []string{"--owner", "g1,g2,g3", "--parallel", "1", "--format", "json"},
+		stdout:      `{"g1":[{"id":"test-user/test-term/1","owner":"test-user","name":"test-term","revision":1,"created-on":"2016-01-02T04:08:16Z","published":true}],"g2":[{"id":"test-user/test-term/1","owner":"test-user","name":"test-term","revision":1,"created-on":"2016-01-02T04:08:16Z","published":true}],"g3":[{"id":"test-user/test-term/1","owner":"test-user","name":"test-term","revision":1,"created-on":"2016-01-02T04:08:16Z","published":true}]}` + "\n",
+		maxInFlight: 1,
+	}, {
+		about:     "first failure aborts",
+		args:      []string{"--owner", "g1,g2,g3"},
+		ownerErrs: map[string]error{"g2": errors.New("g2 failed")},
+		err:       "g2 failed",
+	}, {
+		about:     "keep going",
+		args:      []string{"--owner", "g1,g2,g3", "--keep-going", "--format", "json"},
+		ownerErrs: map[string]error{"g2": errors.Forbiddenf("g2")},
+		err:       `failed to list terms for 1 of 3 owner\(s\)`,
+		stdout:    `{"g1":[{"id":"test-user/test-term/1","owner":"test-user","name":"test-term","revision":1,"created-on":"2016-01-02T04:08:16Z","published":true}],"g3":[{"id":"test-user/test-term/1","owner":"test-user","name":"test-term","revision":1,"created-on":"2016-01-02T04:08:16Z","published":true}]}` + "\n",
+		stderr:    "cannot list terms owned by \"g2\": g2\n",
+	}, {
+		about:  "invalid parallel",
+		args:   []string{"--parallel", "0"},
+		err:    "invalid --parallel value 0, must be at least 1",
+		stderr: "ERROR invalid --parallel value 0, must be at least 1\n",
+	}}
+	for i, test := range tests {
+		c.Logf("running test %d: %s", i, test.about)
+		s.client.ResetCalls()
+		s.client.ownerErrs = test.ownerErrs
+		s.client.maxInFlight = 0
+		ctx, err := cmdtesting.RunCommand(c, cmd.NewListTermsCommand(), test.args...)
+		if test.err != "" {
+			c.Assert(err, gc.ErrorMatches, test.err)
+		} else {
+			c.Assert(err, jc.ErrorIsNil)
+		}
+		c.Assert(cmdtesting.Stdout(ctx), gc.Equals, test.stdout)
+		c.Assert(cmdtesting.Stderr(ctx), gc.Equals, test.stderr)
+		if test.maxInFlight > 0 {
+			c.Assert(s.client.maxInFlight, gc.Equals, test.maxInFlight)
+		}
+	}
 }
 
-func (c *mockClient) setTerms(t []wireformat.Term) {
-	c.lock.Lock()
-	defer c.lock.Unlock()
-	c.terms = t
+func (s *commandSuite) TestListTermsIdentityURL(c *gc.C) {
+	var idmURL string
+	s.PatchValue(cmd.NewIDMClient, func(url string, _ *httpbakery.Client) cmd.IDMClient {
+		idmURL = url
+		return s.idmClient
+	})
+	err := ioutil.WriteFile(s.configFile, []byte(`
+profiles:
+  staging:
+    identity-url: https://identity.staging.example.com
+`), 0600)
+	c.Assert(err, jc.ErrorIsNil)
+	tests := []struct {
+		about string
+		env   string
+		args  []string
+		url   string
+	}{{
+		about: "default",
+		url:   "https://api.jujucharms.com/identity/v1",
+	}, {
+		about: "profile",
+		args:  []string{"--profile", "staging"},
+		url:   "https://identity.staging.example.com",
+	}, {
+		about: "environment",
+		env:   "https://identity.env.example.com",
+		args:  []string{"--profile", "staging"},
+		url:   "https://identity.env.example.com",
+	}, {
+		about: "flag",
+		env:   "https://identity.env.example.com",
+		args:  []string{"--profile", "staging", "--identity-url", "https://identity.flag.example.com"},
+		url:   "https://identity.flag.example.com",
+	}}
+	for i, test := range tests {
+		c.Logf("running test %d: %s", i, test.about)
+		s.PatchEnvironment("JUJU_IDENTITY", test.env)
+		_, err := cmdtesting.RunCommand(c, cmd.NewListTermsCommand(), test.args...)
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(idmURL, gc.Equals, test.url)
+	}
 }
 
-func (c *mockClient) setUnsignedTerms(t []wireformat.Term) {
-	c.lock.Lock()
-	defer c.lock.Unlock()
-	c.unsignedTerms = t
+func (s *commandSuite) TestCache(c *gc.C) {
+	s.client.setTerms([]wireformat.Term{{
+		Id:        "owner/test-term/1",
+		Owner:     "owner",
+		Name:      "test-term",
+		Revision:  1,
+		Published: true,
+		Content:   testTermsAndConditions,
+	}})
+	for i := 0; i < 2; i++ {
+		_, err := cmdtesting.RunCommand(c, cmd.NewShowTermCommand(), "owner/test-term/1")
+		c.Assert(err, jc.ErrorIsNil)
+	}
+	c.Assert(s.client.Calls(), gc.HasLen, 1)
+
+	for i := 0; i < 2; i++ {
+		_, err := cmdtesting.RunCommand(c, cmd.NewShowTermCommand(), "owner/test-term/1", "--no-cache")
+		c.Assert(err, jc.ErrorIsNil)
+	}
+	c.Assert(s.client.Calls(), gc.HasLen, 3)
 }
 
-func (c *mockClient) SaveTerm(_ context.Context, owner, name, content string) (string, error) {
-	c.AddCall("SaveTerm", owner, name, content)
-	if owner == "" {
-		return fmt.Sprintf("%s/1", name), nil
+func (s *commandSuite) TestPushInvalidatesCache(c *gc.C) {
+	server := termstest.NewServer()
+	server.SetNow(func() time.Time {
+		return time.Date(2016, 1, 2, 4, 8, 16, 0, time.UTC)
+	})
+	server.AddTerm("owner", "test-term", "content 1", true)
+	s.PatchValue(cmd.ClientNew, func(...api.ClientOption) (api.Client, error) {
+		return server.Client(), nil
+	})
+	s.PatchValue(cmd.ReadFile, func(string) ([]byte, error) {
+		return []byte("content 2"), nil
+	})
+	showTerm := func() string {
+		ctx, err := cmdtesting.RunCommand(c, cmd.NewShowTermCommand(), "owner/test-term", "--format", "json")
+		c.Assert(err, jc.ErrorIsNil)
+		return cmdtesting.Stdout(ctx)
 	}
-	return fmt.Sprintf("%s/%s/1", owner, name), nil
+	c.Assert(showTerm(), gc.Equals, `{"id":"owner/test-term/1","owner":"owner","name":"test-term","revision":1,"created-on":"2016-01-02T04:08:16Z","published":true,"content":"content 1"}
+`)
+
+	_, err := cmdtesting.RunCommand(c, cmd.NewPushTermCommand(), "terms.txt", "owner/test-term")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(showTerm(), gc.Equals, `{"id":"owner/test-term/2","owner":"owner","name":"test-term","revision":2,"created-on":"2016-01-02T04:08:16Z","published":false,"content":"content 2"}
+`)
+
+	_, err = cmdtesting.RunCommand(c, cmd.NewReleaseTermCommand(), "owner/test-term/2")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(showTerm(), gc.Equals, `{"id":"owner/test-term/2","owner":"owner","name":"test-term","revision":2,"created-on":"2016-01-02T04:08:16Z","published":true,"content":"content 2"}
+`)
 }
 
-// GetTerms returns matching Terms and Conditions documents.
-func (c *mockClient) GetTerm(_ context.Context, owner, name string, revision int) (*wireformat.Term, error) {
-	c.AddCall("GetTerm", owner, name, revision)
-	c.lock.Lock()
-	defer c.lock.Unlock()
+func (s *commandSuite) TestNoCacheFlag(c *gc.C) {
+	_, err := cmdtesting.RunCommand(c, cmd.NewPushTermCommand(), "terms.txt", "owner/test-term", "--no-cache")
+	c.Assert(err, gc.ErrorMatches, "flag provided but not defined: --no-cache")
+	s.client.CheckNoCalls(c)
+}
 
-	if len(c.terms) == 0 {
-		return nil, errors.NotFoundf("term")
+func (s *commandSuite) TestTimeout(c *gc.C) {
+	s.client.delay = time.Minute
+	_, err := cmdtesting.RunCommand(c, cmd.NewListTermsCommand(), "--owner", "g1", "--timeout", "10ms")
+	c.Assert(err, gc.ErrorMatches, "context deadline exceeded")
+	s.client.CheckCalls(c, []jujutesting.StubCall{
+		{FuncName: "GetTermsByOwner", Args: []interface{}{"g1"}},
+	})
+}
+
+func (s *commandSuite) TestInterrupt(c *gc.C) {
+	s.client.delay = time.Minute
+	s.client.called = func() {
+		p, err := os.FindProcess(os.Getpid())
+		c.Check(err, jc.ErrorIsNil)
+		c.Check(p.Signal(os.Interrupt), jc.ErrorIsNil)
+	}
+	for i, args := range [][]string{
+		{"--owner", "g1"},
+		{"--owner", "g1", "--timeout", "1m"},
+	} {
+		c.Logf("running test %d: %v", i, args)
+		_, err := cmdtesting.RunCommand(c, cmd.NewListTermsCommand(), args...)
+		c.Assert(err, gc.ErrorMatches, "context canceled")
 	}
-	t := c.terms[0]
-	return &t, nil
 }
 
-// SaveAgreement saves user's agreement to the specified
-// revision of the Terms and Conditions document.s
-func (c *mockClient) SaveAgreement(_ context.Context, agreements *wireformat.SaveAgreements) (*wireformat.SaveAgreementResponses, error) {
-	c.AddCall("SaveAgreement", agreements)
-	responses := make([]wireformat.AgreementResponse, len(agreements.Agreements))
-	for i, agreement := range agreements.Agreements {
-		responses[i] = wireformat.AgreementResponse{
-			User:     c.user,
-			Owner:    agreement.TermOwner,
-			Term:     agreement.TermName,
-			Revision: agreement.TermRevision,
+func (s *commandSuite) TestAgree(c *gc.C) {
+	s.client.user = "test-user"
+	s.client.setTerms([]wireformat.Term{{
+		Id:       "owner/test-term/1",
+		Owner:    "owner",
+		Name:     "test-term",
+		Title:    "Test Term",
+		Revision: 1,
+		Content:  testTermsAndConditions,
+	}})
+	tests := []struct {
+		about    string
+		args     []string
+		stdin    string
+		err      string
+		stdout   string
+		apiCalls []jujutesting.StubCall
+	}{{
+		about: "agree after prompt",
+		args:  []string{"owner/test-term/1"},
+		stdin: "y\n",
+		stdout: `=== owner/test-term/1: Test Term ===
+Test Terms and Conditions
+========
+Do you agree to the displayed terms? (y/N): Agreed to revision 1 of owner/test-term
+`,
+		apiCalls: []jujutesting.StubCall{
+			{FuncName: "GetTerm", Args: []interface{}{"owner", "test-term", 1}},
+			{FuncName: "SaveAgreement", Args: []interface{}{&wireformat.SaveAgreements{
+				Agreements: []wireformat.SaveAgreement{{TermOwner: "owner", TermName: "test-term", TermRevision: 1}},
+			}}},
+		},
+	}, {
+		about: "disagree after prompt",
+		args:  []string{"owner/test-term/1"},
+		stdin: "n\n",
+		err:   "agreement declined",
+		stdout: `=== owner/test-term/1: Test Term ===
+Test Terms and Conditions
+========
+Do you agree to the displayed terms? (y/N): `,
+		apiCalls: []jujutesting.StubCall{
+			{FuncName: "GetTerm", Args: []interface{}{"owner", "test-term", 1}},
+		},
+	}, {
+		about: "empty answer does not agree",
+		args:  []string{"owner/test-term/1"},
+		stdin: "\n",
+		err:   "agreement declined",
+		stdout: `=== owner/test-term/1: Test Term ===
+Test Terms and Conditions
+========
+Do you agree to the displayed terms? (y/N): `,
+		apiCalls: []jujutesting.StubCall{
+			{FuncName: "GetTerm", Args: []interface{}{"owner", "test-term", 1}},
+		},
+	}, {
+		about: "agree to multiple terms without prompting",
+		args:  []string{"owner/test-term/1", "other-term/2", "--yes"},
+		stdout: `Agreed to revision 1 of owner/test-term
+Agreed to revision 2 of other-term
+`,
+		apiCalls: []jujutesting.StubCall{
+			{FuncName: "SaveAgreement", Args: []interface{}{&wireformat.SaveAgreements{
+				Agreements: []wireformat.SaveAgreement{
+					{TermOwner: "owner", TermName: "test-term", TermRevision: 1},
+					{TermName: "other-term", TermRevision: 2},
+				},
+			}}},
+		},
+	}, {
+		about: "missing revision",
+		args:  []string{"owner/test-term"},
+		err:   `must specify a term revision for "owner/test-term"`,
+	}, {
+		about: "invalid term",
+		args:  []string{"!!!!"},
+		err:   `invalid term format: wrong term name format "!!!!"`,
+	}, {
+		about: "missing arguments",
+		args:  []string{},
+		err:   "missing arguments",
+	}}
+	for i, test := range tests {
+		s.client.ResetCalls()
+		c.Logf("running test %d: %s", i, test.about)
+		ctx := cmdtesting.Context(c)
+		ctx.Stdin = strings.NewReader(test.stdin)
+		command := cmd.NewAgreeCommand()
+		err := cmdtesting.InitCommand(command, test.args)
+		if err == nil {
+			err = command.Run(ctx)
+		}
+		if test.err != "" {
+			c.Assert(err, gc.ErrorMatches, test.err)
+		} else {
+			c.Assert(err, jc.ErrorIsNil)
 		}
+		c.Assert(cmdtesting.Stdout(ctx), gc.Equals, test.stdout)
+		s.client.CheckCalls(c, test.apiCalls)
 	}
-	return &wireformat.SaveAgreementResponses{Agreements: responses}, nil
 }
 
-func (c *mockClient) GetUnsignedTerms(_ context.Context, terms *wireformat.CheckAgreementsRequest) ([]wireformat.GetTermsResponse, error) {
-	c.MethodCall(c, "GetUnunsignedTerms", terms)
-	r := make([]wireformat.GetTermsResponse, len(c.unsignedTerms))
-	for i, term := range c.terms {
-		r[i].Owner = term.Owner
-		r[i].Name = term.Name
-		r[i].Revision = term.Revision
+func (s *commandSuite) TestListAgreements(c *gc.C) {
+	t := time.Date(2016, 1, 2, 4, 8, 16, 0, time.UTC)
+	s.client.agreements = []wireformat.AgreementResponse{{
+		User:      "test-user",
+		Owner:     "owner",
+		Term:      "test-term",
+		Revision:  1,
+		CreatedOn: t,
+	}, {
+		User:      "test-user",
+		Term:      "other-term",
+		Revision:  12,
+		CreatedOn: t,
+	}}
+	tests := []struct {
+		about  string
+		args   []string
+		err    string
+		stdout string
+	}{{
+		about: "tabular",
+		args:  []string{"--format", "tabular"},
+		stdout: `TERM               CREATED
+owner/test-term/1  2016-01-02T04:08:16Z
+other-term/12      2016-01-02T04:08:16Z
+`,
+	}, {
+		about: "tabular with columns sorted by revision",
+		args:  []string{"--format", "tabular", "--columns", "name,revision,user", "--sort", "-revision"},
+		stdout: `NAME        REVISION  USER
+other-term  12        test-user
+test-term   1         test-user
+`,
+	}, {
+		about: "yaml by default",
+		args:  []string{},
+		stdout: `- user: test-user
+  owner: owner
+  term: test-term
+  revision: 1
+  createdon: 2016-01-02T04:08:16Z
+- user: test-user
+  term: other-term
+  revision: 12
+  createdon: 2016-01-02T04:08:16Z
+`,
+	}, {
+		about: "json",
+		args:  []string{"--format", "json"},
+		stdout: `[{"user":"test-user","owner":"owner","term":"test-term","revision":1,"created-on":"2016-01-02T04:08:16Z"},{"user":"test-user","term":"other-term","revision":12,"created-on":"2016-01-02T04:08:16Z"}]
+`,
+	}, {
+		about: "unknown arguments",
+		args:  []string{"unknown"},
+		err:   "unknown arguments: unknown",
+	}}
+	for i, test := range tests {
+		s.client.ResetCalls()
+		c.Logf("running test %d: %s", i, test.about)
+		ctx, err := cmdtesting.RunCommand(c, cmd.NewListAgreementsCommand(), test.args...)
+		if test.err != "" {
+			c.Assert(err, gc.ErrorMatches, test.err)
+			continue
+		}
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(cmdtesting.Stdout(ctx), gc.Equals, test.stdout)
+		s.client.CheckCallNames(c, "GetUsersAgreements")
 	}
-	return r, nil
 }
 
-func (c *mockClient) Publish(_ context.Context, owner, name string, revision int) (string, error) {
-	c.MethodCall(c, "Publish", owner, name, revision)
-	return "owner/name/1", nil
+func (s *commandSuite) TestListAgreementsNone(c *gc.C) {
+	ctx, err := cmdtesting.RunCommand(c, cmd.NewListAgreementsCommand())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cmdtesting.Stdout(ctx), gc.Equals, "")
+	c.Assert(cmdtesting.Stderr(ctx), gc.Equals, "No agreements to display.\n")
 }
 
-func (c *mockClient) GetTermsByOwner(_ context.Context, owner string) ([]wireformat.Term, error) {
-	c.MethodCall(c, "GetTermsByOwner", owner)
-	return c.terms, c.NextErr()
+func (s *commandSuite) TestCheckTerms(c *gc.C) {
+	s.PatchValue(cmd.ReadFile, func(path string) ([]byte, error) {
+		c.Assert(path, gc.Equals, "/charms/test-charm/metadata.yaml")
+		return []byte(`
+name: test-charm
+summary: test charm
+description: test charm
+terms: ["owner/charm-term/3"]
+`), nil
+	})
+	tests := []struct {
+		about    string
+		args     []string
+		unsigned []wireformat.Term
+		err      string
+		stdout   string
+		stderr   string
+		apiCall  []string
+	}{{
+		about:   "all terms agreed to",
+		args:    []string{"owner/test-term/1", "other-term/2"},
+		stderr:  "All terms have been agreed to.\n",
+		apiCall: []string{"owner/test-term/1", "other-term/2"},
+	}, {
+		about: "unsigned terms",
+		args:  []string{"owner/test-term/1", "other-term/2", "--format", "json"},
+		unsigned: []wireformat.Term{{
+			Owner:    "owner",
+			Name:     "test-term",
+			Revision: 1,
+		}},
+		err: `1 term\(s\) not agreed to`,
+		stdout: `[{"name":"test-term","owner":"owner","title":"","revision":1,"created-on":"0001-01-01T00:00:00Z","content":""}]
+`,
+		apiCall: []string{"owner/test-term/1", "other-term/2"},
+	}, {
+		about: "unsigned terms in tabular format",
+		args:  []string{"owner/test-term/1", "other-term/2", "--format", "tabular"},
+		unsigned: []wireformat.Term{{
+			Owner:    "owner",
+			Name:     "test-term",
+			Title:    "Test Term",
+			Revision: 1,
+		}, {
+			Name:     "other-term",
+			Revision: 2,
+		}},
+		err: `2 term\(s\) not agreed to`,
+		stdout: `TERM               TITLE
+owner/test-term/1  Test Term
+other-term/2
+`,
+		apiCall: []string{"owner/test-term/1", "other-term/2"},
+	}, {
+		about:   "terms from charm metadata",
+		args:    []string{"--charm", "/charms/test-charm", "other-term/2"},
+		stderr:  "All terms have been agreed to.\n",
+		apiCall: []string{"other-term/2", "owner/charm-term/3"},
+	}, {
+		about:  "invalid term",
+		args:   []string{"!!!!"},
+		err:    `invalid term format: wrong term name format "!!!!"`,
+		stderr: `ERROR invalid term format: wrong term name format "!!!!"` + "\n",
+	}, {
+		about:  "missing arguments",
+		args:   []string{},
+		err:    "missing arguments",
+		stderr: "ERROR missing arguments\n",
+	}}
+	for i, test := range tests {
+		s.client.ResetCalls()
+		s.client.setUnsignedTerms(test.unsigned)
+		c.Logf("running test %d: %s", i, test.about)
+		ctx, err := cmdtesting.RunCommand(c, cmd.NewCheckTermsCommand(), test.args...)
+		if test.err != "" {
+			c.Assert(err, gc.ErrorMatches, test.err)
+		} else {
+			c.Assert(err, jc.ErrorIsNil)
+		}
+		if ctx != nil {
+			c.Assert(cmdtesting.Stdout(ctx), gc.Equals, test.stdout)
+			c.Assert(cmdtesting.Stderr(ctx), gc.Equals, test.stderr)
+		}
+		if len(test.apiCall) > 0 {
+			s.client.CheckCall(c, 0, "GetUnsignedTerms", &wireformat.CheckAgreementsRequest{Terms: test.apiCall})
+		} else {
+			s.client.CheckNoCalls(c)
+		}
+	}
+}
+
+func (s *commandSuite) TestLintTerm(c *gc.C) {
+	tests := []struct {
+		about   string
+		content string
+		args    []string
+		err     string
+		stdout  string
+		stderr  string
+	}{{
+		about:   "no problems",
+		content: "# Title\nTerms.\n",
+		args:    []string{"terms.md"},
+		stderr:  "No problems found.\n",
+	}, {
+		about:   "warnings only",
+		content: "Terms. TODO\n",
+		args:    []string{"terms.md"},
+		stdout: `1: warning: document does not start with a title (title)
+1: warning: placeholder text "TODO" (placeholder)
+`,
+	}, {
+		about:   "errors in json",
+		content: "# Title\n[link](terms.md)\n",
+		args:    []string{"terms.md", "--format", "json"},
+		stdout:  `[{"line":2,"rule":"link","severity":"error","message":"link \"link\": relative URL \"terms.md\""}]` + "\n",
+		err:     `1 lint error\(s\) found`,
+	}, {
+		about:   "max size",
+		content: "# Title\nTerms.\n",
+		args:    []string{"terms.md", "--max-size", "4"},
+		stdout:  "error: document is 15 bytes, exceeding the limit of 4 bytes (size)\n",
+		err:     `1 lint error\(s\) found`,
+	}, {
+		about:  "missing arguments",
+		err:    "missing arguments",
+		stderr: "ERROR missing arguments\n",
+	}}
+	for i, test := range tests {
+		c.Logf("running test %d: %s", i, test.about)
+		content := test.content
+		s.PatchValue(cmd.ReadFile, func(string) ([]byte, error) {
+			return []byte(content), nil
+		})
+		ctx, err := cmdtesting.RunCommand(c, cmd.NewLintTermCommand(), test.args...)
+		if test.err != "" {
+			c.Assert(err, gc.ErrorMatches, test.err)
+		} else {
+			c.Assert(err, jc.ErrorIsNil)
+		}
+		c.Assert(cmdtesting.Stdout(ctx), gc.Equals, test.stdout)
+		c.Assert(cmdtesting.Stderr(ctx), gc.Equals, test.stderr)
+	}
+}
+
+func (s *commandSuite) TestPushTermLineEndings(c *gc.C) {
+	s.PatchValue(cmd.ReadFile, func(string) ([]byte, error) {
+		return []byte("line 1\r\nline 2\r\n"), nil
+	})
+	s.client.setTerms([]wireformat.Term{{
+		Owner:    "owner",
+		Name:     "test-term",
+		Revision: 3,
+		Content:  "line 1\nline 2\n",
+	}})
+	ctx, err := cmdtesting.RunCommand(c, cmd.NewPushTermCommand(), "terms.txt", "owner/test-term")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cmdtesting.Stdout(ctx), gc.Equals, "owner/test-term/3\n")
+	s.client.CheckCallNames(c, "GetTerm")
+}
+
+func (s *commandSuite) TestPushTermRelease(c *gc.C) {
+	server := termstest.NewServer()
+	server.AddTerm("owner", "unreleased-term", testTermsAndConditions, false)
+	server.AddTerm("owner", "released-term", testTermsAndConditions, true)
+	var client api.Client = server.Client()
+	s.PatchValue(cmd.ClientNew, func(...api.ClientOption) (api.Client, error) {
+		return client, nil
+	})
+	tests := []struct {
+		about  string
+		args   []string
+		client api.Client
+		err    string
+		stdout string
+	}{{
+		about: "new revision",
+		args:  []string{"terms.txt", "owner/new-term", "--release"},
+		stdout: `term-id: owner/new-term/1
+released-id: owner/new-term/1
+`,
+	}, {
+		about: "unchanged unreleased revision",
+		args:  []string{"terms.txt", "owner/unreleased-term", "--release"},
+		stdout: `term-id: owner/unreleased-term/1
+released-id: owner/unreleased-term/1
+`,
+	}, {
+		about: "unchanged released revision",
+		args:  []string{"terms.txt", "owner/released-term", "--release", "--format", "json"},
+		stdout: `{"term-id":"owner/released-term/1","released-id":"owner/released-term/1"}
+`,
+	}, {
+		about:  "release fails",
+		args:   []string{"terms.txt", "owner/other-term", "--release"},
+		client: &failingPublishClient{server.Client()},
+		err:    `saved term "owner/other-term/1" but failed to release it: service unavailable`,
+		stdout: `term-id: owner/other-term/1
+`,
+	}, {
+		about: "dry run",
+		args:  []string{"terms.txt", "owner/another-term", "--release", "--dry-run"},
+		stdout: `term: owner/another-term/1
+unchanged: false
+lines-added: 1
+lines-removed: 0
+release: true
+`,
+	}}
+	for i, test := range tests {
+		c.Logf("running test %d: %s", i, test.about)
+		client = server.Client()
+		if test.client != nil {
+			client = test.client
+		}
+		ctx, err := cmdtesting.RunCommand(c, cmd.NewPushTermCommand(), test.args...)
+		if test.err != "" {
+			c.Assert(err, gc.ErrorMatches, test.err)
+		} else {
+			c.Assert(err, jc.ErrorIsNil)
+		}
+		c.Assert(cmdtesting.Stdout(ctx), gc.Equals, test.stdout)
+	}
+	published := make(map[string]bool)
+	for _, t := range server.Terms() {
+		published[t.Id] = t.Published
+	}
+	c.Assert(published, jc.DeepEquals, map[string]bool{
+		"owner/unreleased-term/1": true,
+		"owner/released-term/1":   true,
+		"owner/new-term/1":        true,
+		"owner/other-term/1":      false,
+	})
+}
+
+// failingPublishClient fails all Publish calls.
+type failingPublishClient struct {
+	api.Client
+}
+
+func (c *failingPublishClient) Publish(ctx context.Context, owner, name string, revision int) (string, error) {
+	return "", errors.New("service unavailable")
+}
+
+func (s *commandSuite) TestPushTerms(c *gc.C) {
+	server := termstest.NewServer()
+	server.AddTerm("owner", "unchanged-term", testTermsAndConditions, true)
+	var client api.Client = server.Client()
+	s.PatchValue(cmd.ClientNew, func(...api.ClientOption) (api.Client, error) {
+		return client, nil
+	})
+	s.PatchValue(cmd.ReadFile, ioutil.ReadFile)
+	writeFiles := func(files map[string]string) string {
+		dir := c.MkDir()
+		for name, content := range files {
+			path := filepath.Join(dir, filepath.FromSlash(name))
+			err := os.MkdirAll(filepath.Dir(path), 0755)
+			c.Assert(err, jc.ErrorIsNil)
+			err = ioutil.WriteFile(path, []byte(content), 0644)
+			c.Assert(err, jc.ErrorIsNil)
+		}
+		return dir
+	}
+	tests := []struct {
+		about  string
+		files  map[string]string
+		args   []string
+		client api.Client
+		err    string
+		stdout string
+		stderr string
+	}{{
+		about: "documents found by path",
+		files: map[string]string{
+			"owner/unchanged-term.md": testTermsAndConditions,
+			"owner/new-term.txt":      "# New term\n",
+			"ownerless-term.md":       "# Ownerless term\n",
+			"owner/notes.pdf":         "ignored",
+			"README.md":               "ignored",
+			"term/1.md":               "ignored",
+			".hidden/term.md":         "ignored",
+			"owner/nested/term.md":    "ignored",
+			"Owner/Plan.md":           "ignored",
+		},
+		stdout: `- file: owner/new-term.txt
+  term: owner/new-term
+  status: created
+  term-id: owner/new-term/1
+- file: owner/unchanged-term.md
+  term: owner/unchanged-term
+  status: unchanged
+  term-id: owner/unchanged-term/1
+- file: ownerless-term.md
+  term: ownerless-term
+  status: created
+  term-id: ownerless-term/1
+`,
+		stderr: `Ignoring Owner/Plan.md: wrong term name format "Plan"
+Ignoring README.md: wrong term name format "README"
+Ignoring term/1.md: can't specify a revision with a new term
+`,
+	}, {
+		about: "documents listed in the manifest",
+		files: map[string]string{
+			"terms.yaml": `terms:
+- file: plans/enterprise.md
+  term: owner/enterprise-plan
+`,
+			"plans/enterprise.md": "# Enterprise plan\n",
+			"owner/ignored.md":    "# Ignored\n",
+		},
+		args: []string{"--release", "--format", "tabular"},
+		stdout: `TERM                   STATUS    ID                       ERROR
+owner/enterprise-plan  released  owner/enterprise-plan/1
+`,
+	}, {
+		about: "forced push",
+		files: map[string]string{
+			"owner/unchanged-term.md": testTermsAndConditions,
+		},
+		args: []string{"--force", "--format", "json"},
+		stdout: `[{"file":"owner/unchanged-term.md","term":"owner/unchanged-term","status":"created","term-id":"owner/unchanged-term/2"}]
+`,
+	}, {
+		about: "release fails",
+		files: map[string]string{
+			"owner/failing-term.md": "# Failing term\n",
+		},
+		args:   []string{"--release", "--format", "tabular", "--columns", "term,status,error"},
+		client: &failingPublishClient{server.Client()},
+		err:    `failed to push 1 of 1 document\(s\)`,
+		stdout: `TERM                STATUS  ERROR
+owner/failing-term  failed  saved term "owner/failing-term/1" but failed to release it: service unavailable
+`,
+	}, {
+		about: "no documents",
+		files: map[string]string{
+			"README": "nothing to push",
+		},
+		stderr: "No documents found in .*\n",
+	}, {
+		about: "duplicate terms",
+		files: map[string]string{
+			"terms.yaml": `terms:
+- file: a.md
+  term: owner/term
+- file: b.md
+  term: owner/term
+`,
+		},
+		err: `both "a.md" and "b.md" map to term "owner/term"`,
+	}, {
+		about: "file outside the directory",
+		files: map[string]string{
+			"terms.yaml": `terms:
+- file: ../a.md
+  term: owner/term
+`,
+		},
+		err: `invalid manifest ".*": file "../a.md" is outside the directory`,
+	}, {
+		about: "file name starting with two dots",
+		files: map[string]string{
+			"terms.yaml": `terms:
+- file: ..term.md
+  term: owner/dotted-term
+`,
+			"..term.md": "# Dotted term\n",
+		},
+		args: []string{"--format", "tabular"},
+		stdout: `TERM               STATUS   ID                   ERROR
+owner/dotted-term  created  owner/dotted-term/1
+`,
+	}, {
+		about: "revision in the manifest",
+		files: map[string]string{
+			"terms.yaml": `terms:
+- file: term.md
+  term: owner/term/1
+`,
+		},
+		err: `invalid manifest ".*": invalid term for "term.md": can't specify a revision with a new term`,
+	}}
+	for i, test := range tests {
+		c.Logf("running test %d: %s", i, test.about)
+		client = server.Client()
+		if test.client != nil {
+			client = test.client
+		}
+		dir := writeFiles(test.files)
+		ctx, err := cmdtesting.RunCommand(c, cmd.NewPushTermsCommand(), append([]string{dir}, test.args...)...)
+		if test.err != "" {
+			c.Assert(err, gc.ErrorMatches, test.err)
+		} else {
+			c.Assert(err, jc.ErrorIsNil)
+		}
+		c.Assert(cmdtesting.Stdout(ctx), gc.Equals, test.stdout)
+		c.Assert(cmdtesting.Stderr(ctx), gc.Matches, test.stderr)
+	}
+}
+
+func (s *commandSuite) TestPushTermsTimeout(c *gc.C) {
+	client := &blockingClient{}
+	s.PatchValue(cmd.ClientNew, func(...api.ClientOption) (api.Client, error) {
+		return client, nil
+	})
+	dir := c.MkDir()
+	for _, name := range []string{"term-a.md", "term-b.md", "term-c.md"} {
+		err := ioutil.WriteFile(filepath.Join(dir, name), []byte("# Term\n"), 0644)
+		c.Assert(err, jc.ErrorIsNil)
+	}
+	ctx, err := cmdtesting.RunCommand(c, cmd.NewPushTermsCommand(), dir, "--parallel", "1", "--timeout", "10ms", "--format", "tabular", "--columns", "term,status")
+	c.Assert(err, gc.ErrorMatches, `failed to push 3 of 3 document\(s\)`)
+	c.Assert(cmdtesting.Stdout(ctx), gc.Equals, `TERM    STATUS
+term-a  failed
+term-b  failed
+term-c  failed
+`)
+	// The documents waiting for the first one are not pushed once the
+	// timeout expires.
+	c.Assert(atomic.LoadInt32(&client.calls), gc.Equals, int32(1))
+}
+
+// blockingClient is a client whose GetTerm calls block until their
+// context is done.
+type blockingClient struct {
+	api.Client
+	calls int32
+}
+
+func (c *blockingClient) GetTerm(ctx context.Context, owner, name string, revision int) (*wireformat.Term, error) {
+	atomic.AddInt32(&c.calls, 1)
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (s *commandSuite) TestPushTermLint(c *gc.C) {
+	s.PatchValue(cmd.ReadFile, func(string) ([]byte, error) {
+		return []byte("# Title\n[link]()\n"), nil
+	})
+	ctx, err := cmdtesting.RunCommand(c, cmd.NewPushTermCommand(), "terms.md", "owner/test-term", "--lint")
+	c.Assert(err, gc.ErrorMatches, `not pushing "terms.md": 1 lint error\(s\) found`)
+	c.Assert(cmdtesting.Stderr(ctx), gc.Equals, "2: error: link \"link\" has no target (link)\n")
+	s.client.CheckNoCalls(c)
+
+	s.PatchValue(cmd.ReadFile, func(string) ([]byte, error) {
+		return []byte("Terms.\n"), nil
+	})
+	ctx, err = cmdtesting.RunCommand(c, cmd.NewPushTermCommand(), "terms.md", "owner/test-term", "--lint")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cmdtesting.Stderr(ctx), gc.Equals, "1: warning: document does not start with a title (title)\n")
+	c.Assert(cmdtesting.Stdout(ctx), gc.Equals, "owner/test-term/1\n")
+	s.client.CheckCall(c, 1, "SaveTerm", "owner", "test-term", "Terms.\n")
+}
+
+func (s *commandSuite) TestRenderTerm(c *gc.C) {
+	files := map[string]string{
+		"terms.md.tmpl": "# {{.company}} Terms\nGoverned by the laws of {{.jurisdiction}}.\n",
+		"acme.yaml":     "company: Acme Corp\njurisdiction: England\n",
+		"invalid.yaml":  "company: [\n",
+		"broken.tmpl":   "{{.company\n",
+	}
+	s.PatchValue(cmd.ReadFile, func(path string) ([]byte, error) {
+		content, ok := files[path]
+		if !ok {
+			return nil, errors.NotFoundf("file %q", path)
+		}
+		return []byte(content), nil
+	})
+	tests := []struct {
+		about  string
+		args   []string
+		err    string
+		stdout string
+	}{{
+		about:  "values set on the command line",
+		args:   []string{"terms.md.tmpl", "--set", "company=Acme Corp", "--set", "jurisdiction=Scotland"},
+		stdout: "# Acme Corp Terms\nGoverned by the laws of Scotland.\n",
+	}, {
+		about:  "values file",
+		args:   []string{"terms.md.tmpl", "--values", "acme.yaml"},
+		stdout: "# Acme Corp Terms\nGoverned by the laws of England.\n",
+	}, {
+		about:  "values set on the command line override the values file",
+		args:   []string{"terms.md.tmpl", "--values", "acme.yaml", "--set", "jurisdiction=Wales"},
+		stdout: "# Acme Corp Terms\nGoverned by the laws of Wales.\n",
+	}, {
+		about: "missing value",
+		args:  []string{"terms.md.tmpl", "--set", "company=Acme Corp"},
+		err:   `cannot render template "terms.md.tmpl": .*map has no entry for key "jurisdiction"`,
+	}, {
+		about: "invalid --set",
+		args:  []string{"terms.md.tmpl", "--set", "company"},
+		err:   `invalid value "company" for flag --set: expected key=value, got "company"`,
+	}, {
+		about: "invalid values file",
+		args:  []string{"terms.md.tmpl", "--values", "invalid.yaml"},
+		err:   `invalid values file "invalid.yaml": .*`,
+	}, {
+		about: "missing values file",
+		args:  []string{"terms.md.tmpl", "--values", "missing.yaml"},
+		err:   `could not read values from "missing.yaml": file "missing.yaml" not found`,
+	}, {
+		about: "invalid template",
+		args:  []string{"broken.tmpl", "--set", "company=Acme Corp"},
+		err:   `cannot parse template "broken.tmpl": .*`,
+	}, {
+		about: "missing template",
+		args:  []string{"missing.tmpl"},
+		err:   `could not read contents of "missing.tmpl": file "missing.tmpl" not found`,
+	}, {
+		about: "missing arguments",
+		err:   "missing arguments",
+	}}
+	for i, test := range tests {
+		c.Logf("running test %d: %s", i, test.about)
+		ctx, err := cmdtesting.RunCommand(c, cmd.NewRenderTermCommand(), test.args...)
+		if test.err != "" {
+			c.Assert(err, gc.ErrorMatches, test.err)
+			continue
+		}
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(cmdtesting.Stdout(ctx), gc.Equals, test.stdout)
+	}
+}
+
+func (s *commandSuite) TestPushTermTemplate(c *gc.C) {
+	files := map[string]string{
+		"terms.md.tmpl": "# {{.company}} Terms\nEffective from {{.date}}.\n",
+		"acme.yaml":     "company: Acme Corp\ndate: 2020-01-01\n",
+	}
+	s.PatchValue(cmd.ReadFile, func(path string) ([]byte, error) {
+		return []byte(files[path]), nil
+	})
+	ctx, err := cmdtesting.RunCommand(c, cmd.NewPushTermCommand(), "terms.md.tmpl", "owner/test-term", "--values", "acme.yaml", "--set", "date=1 January 2020")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cmdtesting.Stdout(ctx), gc.Equals, "owner/test-term/1\n")
+	s.client.CheckCall(c, 1, "SaveTerm", "owner", "test-term", "# Acme Corp Terms\nEffective from 1 January 2020.\n")
+
+	s.client.ResetCalls()
+	_, err = cmdtesting.RunCommand(c, cmd.NewPushTermCommand(), "terms.md.tmpl", "owner/test-term", "--set", "company=Acme Corp")
+	c.Assert(err, gc.ErrorMatches, `cannot render template "terms.md.tmpl": .*map has no entry for key "date"`)
+	s.client.CheckNoCalls(c)
+
+	// Without values the document is pushed as is.
+	_, err = cmdtesting.RunCommand(c, cmd.NewPushTermCommand(), "terms.md.tmpl", "owner/test-term")
+	c.Assert(err, jc.ErrorIsNil)
+	s.client.CheckCall(c, 1, "SaveTerm", "owner", "test-term", "# {{.company}} Terms\nEffective from {{.date}}.\n")
+}
+
+func (s *commandSuite) TestDryRun(c *gc.C) {
+	server := termstest.NewServer()
+	server.AddTerm("owner", "test-term", "line 1\nline 2\n", true)
+	server.AddTerm("owner", "test-term", "line 1\nline two\nline 3\n", false)
+	s.PatchValue(cmd.ClientNew, func(...api.ClientOption) (api.Client, error) {
+		return server.Client(), nil
+	})
+	content := "line 1\nline two\nline 3\n"
+	s.PatchValue(cmd.ReadFile, func(string) ([]byte, error) {
+		return []byte(content), nil
+	})
+	tests := []struct {
+		about   string
+		command func() jujucmd.Command
+		args    []string
+		content string
+		err     string
+		stdout  string
+	}{{
+		about:   "push unchanged content",
+		command: cmd.NewPushTermCommand,
+		args:    []string{"terms.txt", "owner/test-term", "--dry-run"},
+		content: "line 1\nline two\nline 3\n",
+		stdout: `previous: owner/test-term/2
+unchanged: true
+lines-added: 0
+lines-removed: 0
+`,
+	}, {
+		about:   "push unchanged content with different line endings",
+		command: cmd.NewPushTermCommand,
+		args:    []string{"terms.txt", "owner/test-term", "--dry-run"},
+		content: "line 1\r\nline two\r\nline 3\r\n",
+		stdout: `previous: owner/test-term/2
+unchanged: true
+lines-added: 0
+lines-removed: 0
+`,
+	}, {
+		about:   "force push unchanged content",
+		command: cmd.NewPushTermCommand,
+		args:    []string{"terms.txt", "owner/test-term", "--dry-run", "--force"},
+		content: "line 1\nline two\nline 3\n",
+		stdout: `term: owner/test-term/3
+previous: owner/test-term/2
+unchanged: true
+lines-added: 0
+lines-removed: 0
+`,
+	}, {
+		about:   "push changed content",
+		command: cmd.NewPushTermCommand,
+		args:    []string{"terms.txt", "owner/test-term", "--dry-run", "--format", "json"},
+		content: "line 1\nline 2\nline 3\nline 4\n",
+		stdout:  `{"term":"owner/test-term/3","previous":"owner/test-term/2","unchanged":false,"lines-added":2,"lines-removed":1}` + "\n",
+	}, {
+		about:   "push new term",
+		command: cmd.NewPushTermCommand,
+		args:    []string{"terms.txt", "owner/new-term", "--dry-run"},
+		content: "line 1\n",
+		stdout: `term: owner/new-term/1
+unchanged: false
+lines-added: 1
+lines-removed: 0
+`,
+	}, {
+		about:   "push invalid term",
+		command: cmd.NewPushTermCommand,
+		args:    []string{"terms.txt", "owner/test-term/1", "--dry-run"},
+		err:     "can't specify a revision with a new term",
+	}, {
+		about:   "release unpublished revision",
+		command: cmd.NewReleaseTermCommand,
+		args:    []string{"owner/test-term/2", "--dry-run"},
+		stdout: `term: owner/test-term/2
+already-released: false
+latest: owner/test-term/2
+`,
+	}, {
+		about:   "release published revision",
+		command: cmd.NewReleaseTermCommand,
+		args:    []string{"owner/test-term/1", "--dry-run"},
+		stdout: `term: owner/test-term/1
+already-released: true
+latest: owner/test-term/2
+`,
+	}, {
+		about:   "release missing revision",
+		command: cmd.NewReleaseTermCommand,
+		args:    []string{"owner/test-term/5", "--dry-run"},
+		err:     `failed to retrieve term "owner/test-term/5": .*not found`,
+	}}
+	for i, test := range tests {
+		c.Logf("running test %d: %s", i, test.about)
+		content = test.content
+		ctx, err := cmdtesting.RunCommand(c, test.command(), test.args...)
+		if test.err != "" {
+			c.Assert(err, gc.ErrorMatches, test.err)
+			continue
+		}
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(cmdtesting.Stdout(ctx), gc.Equals, test.stdout)
+		c.Assert(cmdtesting.Stderr(ctx), gc.Matches, "Dry run: nothing was (pushed|released).\n")
+	}
+	terms := server.Terms()
+	c.Assert(terms, gc.HasLen, 2)
+	c.Assert(terms[1].Published, jc.IsFalse)
+}
+
+func (s *commandSuite) TestExportAgreements(c *gc.C) {
+	server := termstest.NewServer()
+	server.SetNow(func() time.Time { return time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC) })
+	server.AddTerm("owner", "agreed-term", "agreed content", true)
+	server.AddTerm("owner", "other-term", "other content", true)
+	client := server.Client()
+	_, err := client.SaveAgreement(context.Background(), &wireformat.SaveAgreements{
+		Agreements: []wireformat.SaveAgreement{{TermOwner: "owner", TermName: "agreed-term", TermRevision: 1}},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	s.PatchValue(cmd.ClientNew, func(...api.ClientOption) (api.Client, error) {
+		return client, nil
+	})
+	s.PatchValue(cmd.ReadFile, ioutil.ReadFile)
+
+	path := filepath.Join(c.MkDir(), "agreements.json")
+	ctx, err := cmdtesting.RunCommand(c, cmd.NewExportAgreementsCommand(), "-o", path, "owner/other-term", "owner/agreed-term/1")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cmdtesting.Stderr(ctx), gc.Equals, "Exported 1 agreement(s) and 2 term(s) to "+path+"\n")
+
+	f, err := os.Open(path)
+	c.Assert(err, jc.ErrorIsNil)
+	defer f.Close()
+	bundle, err := api.ReadBundle(f)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(bundle.User, gc.Equals, termstest.DefaultUser)
+	c.Assert(bundle.Agreements, gc.HasLen, 1)
+	c.Assert(bundle.Terms, gc.HasLen, 2)
+	c.Assert(bundle.Terms[0].Id, gc.Equals, "owner/agreed-term/1")
+	c.Assert(bundle.Terms[0].Content, gc.Equals, "agreed content")
+	c.Assert(bundle.Terms[1].Id, gc.Equals, "owner/other-term/1")
+
+	ctx, err = cmdtesting.RunCommand(c, cmd.NewCheckTermsCommand(), "--bundle", path, "owner/agreed-term/1")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cmdtesting.Stderr(ctx), gc.Equals, "All terms have been agreed to.\n")
+
+	ctx, err = cmdtesting.RunCommand(c, cmd.NewCheckTermsCommand(), "--bundle", path, "owner/agreed-term/1", "owner/other-term/1", "--format", "json")
+	c.Assert(err, gc.ErrorMatches, `1 term\(s\) not agreed to`)
+	c.Assert(cmdtesting.Stdout(ctx), gc.Equals, `[{"name":"other-term","owner":"owner","title":"","revision":1,"created-on":"2020-01-02T03:04:05Z","content":"other content"}]`+"\n")
+
+	ctx, err = cmdtesting.RunCommand(c, cmd.NewExportAgreementsCommand())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cmdtesting.Stdout(ctx), jc.Contains, `"id": "owner/agreed-term/1"`)
+
+	_, err = cmdtesting.RunCommand(c, cmd.NewExportAgreementsCommand(), "owner/missing-term")
+	c.Assert(err, gc.ErrorMatches, `failed to retrieve term "owner/missing-term": .*not found`)
+
+	_, err = cmdtesting.RunCommand(c, cmd.NewCheckTermsCommand(), "--bundle", filepath.Join(c.MkDir(), "missing.json"), "owner/agreed-term/1")
+	c.Assert(err, gc.ErrorMatches, `could not read contents of ".*missing.json": .*`)
+}
+
+func (s *commandSuite) TestDiffTerm(c *gc.C) {
+	server := termstest.NewServer()
+	server.AddTerm("owner", "test-term", "line 1\nline 2\nline 3\n", true)
+	server.AddTerm("owner", "test-term", "line 1\nline two\nline 3\nline 4\n", false)
+	server.AddTerm("owner", "test-term", "line 1\nline two\nline 3\nline 4\n", false)
+	server.AddTerm("owner", "single-term", "content", false)
+	s.PatchValue(cmd.ClientNew, func(...api.ClientOption) (api.Client, error) {
+		return server.Client(), nil
+	})
+	tests := []struct {
+		about  string
+		args   []string
+		err    string
+		stdout string
+	}{{
+		about: "explicit revisions",
+		args:  []string{"owner/test-term/1", "owner/test-term/2"},
+		stdout: `published: true -> false
+--- owner/test-term/1
++++ owner/test-term/2
+@@ -1,3 +1,4 @@
+ line 1
+-line 2
++line two
+ line 3
++line 4
+`,
+	}, {
+		about: "previous revision",
+		args:  []string{"owner/test-term/2"},
+		stdout: `published: true -> false
+--- owner/test-term/1
++++ owner/test-term/2
+@@ -1,3 +1,4 @@
+ line 1
+-line 2
++line two
+ line 3
++line 4
+`,
+	}, {
+		about: "latest revision",
+		args:  []string{"owner/test-term"},
+		stdout: `no differences between owner/test-term/2 and owner/test-term/3
+`,
+	}, {
+		about: "json",
+		args:  []string{"owner/test-term/1", "owner/test-term/2", "--format", "json"},
+		stdout: `{"from":"owner/test-term/1","to":"owner/test-term/2","published":{"from":true,"to":false},"content":[{"op":"replace","from-line":2,"to-line":2,"removed":["line 2"],"added":["line two"]},{"op":"insert","from-line":4,"to-line":4,"added":["line 4"]}]}
+`,
+	}, {
+		about: "no previous revision",
+		args:  []string{"owner/single-term"},
+		err:   `term "owner/single-term/1" has no previous revision`,
+	}, {
+		about: "unknown revision",
+		args:  []string{"owner/test-term/1", "owner/test-term/7"},
+		err:   `failed to retrieve term "owner/test-term/7": term "owner/test-term/7" not found`,
+	}, {
+		about: "invalid term",
+		args:  []string{"!!!!"},
+		err:   `invalid term format: wrong term name format "!!!!"`,
+	}, {
+		about: "too many arguments",
+		args:  []string{"a/1", "a/2", "a/3"},
+		err:   `unknown arguments: a/3`,
+	}, {
+		about: "missing arguments",
+		args:  []string{},
+		err:   `missing arguments`,
+	}}
+	for i, test := range tests {
+		c.Logf("running test %d: %s", i, test.about)
+		ctx, err := cmdtesting.RunCommand(c, cmd.NewDiffTermCommand(), test.args...)
+		if test.err != "" {
+			c.Assert(err, gc.ErrorMatches, test.err)
+			continue
+		}
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(cmdtesting.Stdout(ctx), gc.Equals, test.stdout)
+	}
+}
+
+func (s *commandSuite) TestTermHistory(c *gc.C) {
+	server := termstest.NewServer()
+	server.SetNow(func() time.Time {
+		return time.Date(2016, 1, 2, 4, 8, 16, 0, time.UTC)
+	})
+	server.AddTerm("owner", "test-term", "first", true)
+	server.AddTerm("owner", "test-term", "second", false)
+	s.PatchValue(cmd.ClientNew, func(...api.ClientOption) (api.Client, error) {
+		return server.Client(), nil
+	})
+	tests := []struct {
+		about    string
+		args     []string
+		terminal bool
+		err      string
+		stdout   string
+	}{{
+		about:    "tabular on a terminal",
+		args:     []string{"owner/test-term"},
+		terminal: true,
+		stdout: `REVISION  PUBLISHED  TITLE  CREATED
+1         true              2016-01-02T04:08:16Z
+2         false             2016-01-02T04:08:16Z
+`,
+	}, {
+		about: "yaml when piped",
+		args:  []string{"owner/test-term"},
+		stdout: `- id: owner/test-term/1
+  owner: owner
+  name: test-term
+  revision: 1
+  createdon: "2016-01-02T04:08:16Z"
+  published: true
+- id: owner/test-term/2
+  owner: owner
+  name: test-term
+  revision: 2
+  createdon: "2016-01-02T04:08:16Z"
+  published: false
+`,
+	}, {
+		about: "unknown term",
+		args:  []string{"owner/unknown"},
+		err:   `term "owner/unknown" not found`,
+	}, {
+		about: "revision specified",
+		args:  []string{"owner/test-term/1"},
+		err:   `can't specify a revision when listing revisions`,
+	}, {
+		about: "missing arguments",
+		args:  []string{},
+		err:   `missing arguments`,
+	}}
+	for i, test := range tests {
+		c.Logf("running test %d: %s", i, test.about)
+		s.PatchValue(cmd.IsTerminal, func(*os.File) bool { return test.terminal })
+		ctx, err := cmdtesting.RunCommand(c, cmd.NewTermHistoryCommand(), test.args...)
+		if test.err != "" {
+			c.Assert(err, gc.ErrorMatches, test.err)
+			continue
+		}
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(cmdtesting.Stdout(ctx), gc.Equals, test.stdout)
+	}
+}
+
+func (s *commandSuite) TestTermsStatus(c *gc.C) {
+	server := termstest.NewServer()
+	s.PatchValue(cmd.ClientNew, func(...api.ClientOption) (api.Client, error) {
+		return server.Client(), nil
+	})
+	tests := []struct {
+		about    string
+		checks   map[string]wireformat.CheckResult
+		args     []string
+		terminal bool
+		err      string
+		stdout   string
+	}{{
+		about:    "all checks passed",
+		terminal: true,
+		checks: map[string]wireformat.CheckResult{
+			"server_started": {
+				Name:   "Server started",
+				Value:  "Started",
+				Passed: true,
+			},
+			"mongo_connected": {
+				Name:     "MongoDB is connected",
+				Value:    "Connected",
+				Passed:   true,
+				Duration: 1500 * time.Microsecond,
+			},
+		},
+		stdout: `CHECK            STATUS  VALUE      DURATION
+mongo_connected  pass    Connected  1.5ms
+server_started   pass    Started    0s
+`,
+	}, {
+		about: "check failed, yaml when piped",
+		checks: map[string]wireformat.CheckResult{
+			"mongo_connected": {
+				Name:     "MongoDB is connected",
+				Value:    "Not connected",
+				Duration: 2 * time.Second,
+			},
+			"server_started": {
+				Name:   "Server started",
+				Value:  "Started",
+				Passed: true,
+			},
+		},
+		err: `1 of 2 check\(s\) failed`,
+		stdout: `- check: mongo_connected
+  name: MongoDB is connected
+  status: fail
+  value: Not connected
+  duration: 2s
+- check: server_started
+  name: Server started
+  status: pass
+  value: Started
+  duration: 0s
+`,
+	}, {
+		about: "no checks",
+		args:  []string{"--format", "json"},
+		stdout: `[]
+`,
+	}, {
+		about: "unknown arguments",
+		args:  []string{"extra"},
+		err:   "unknown arguments: extra",
+	}}
+	for i, test := range tests {
+		c.Logf("running test %d: %s", i, test.about)
+		s.PatchValue(cmd.IsTerminal, func(*os.File) bool { return test.terminal })
+		server.SetChecks(test.checks)
+		ctx, err := cmdtesting.RunCommand(c, cmd.NewTermsStatusCommand(), test.args...)
+		if test.err != "" {
+			c.Assert(err, gc.ErrorMatches, test.err)
+		} else {
+			c.Assert(err, jc.ErrorIsNil)
+		}
+		if ctx != nil {
+			c.Assert(cmdtesting.Stdout(ctx), gc.Equals, test.stdout)
+		}
+	}
+}
+
+func (s *commandSuite) TestSuperCommand(c *gc.C) {
+	server := termstest.NewServer()
+	server.SetNow(func() time.Time {
+		return time.Date(2016, 1, 2, 4, 8, 16, 0, time.UTC)
+	})
+	server.AddTerm("owner", "test-term", "# Test term", true)
+	s.PatchValue(cmd.ClientNew, func(...api.ClientOption) (api.Client, error) {
+		return server.Client(), nil
+	})
+	files := map[string]string{
+		"terms.md.tmpl": "# {{.company}} terms\n",
+		"terms.md":      "# Terms \n",
+	}
+	s.PatchValue(cmd.ReadFile, func(path string) ([]byte, error) {
+		return []byte(files[path]), nil
+	})
+	tests := []struct {
+		about  string
+		args   []string
+		err    string
+		stdout string
+	}{{
+		about: "subcommand",
+		args:  []string{"show-term", "owner/test-term", "--format", "json"},
+		stdout: `{"id":"owner/test-term/1","owner":"owner","name":"test-term","revision":1,"created-on":"2016-01-02T04:08:16Z","published":true,"content":"# Test term"}
+`,
+	}, {
+		about: "global flags before the subcommand",
+		args:  []string{"--format", "yaml", "--no-cache", "--timeout", "1m", "terms-status"},
+		stdout: `- check: server_started
+  name: Server started
+  status: pass
+  value: Started
+  duration: 0s
+`,
+	}, {
+		about: "subcommand flags take precedence",
+		args:  []string{"--format", "yaml", "terms-status", "--format", "tabular", "--columns", "check,status"},
+		stdout: `CHECK           STATUS
+server_started  pass
+`,
+	}, {
+		about:  "global flags not defined by the subcommand",
+		args:   []string{"--url", "https://terms.example.com", "--format", "json", "render-term", "terms.md.tmpl", "--set", "company=Acme Corp"},
+		stdout: "# Acme Corp terms\n",
+	}, {
+		about: "global flags partly defined by the subcommand",
+		args:  []string{"--url", "https://terms.example.com", "--format", "json", "lint-term", "terms.md"},
+		stdout: `[{"line":1,"rule":"trailing-whitespace","severity":"warning","message":"trailing whitespace"}]
+`,
+	}, {
+		about: "global flags without a subcommand",
+		args:  []string{"-B", "help"},
+		err:   "global flags must be followed by a terms command",
+	}, {
+		about: "unknown subcommand",
+		args:  []string{"unknown"},
+		err:   "unrecognized command: charm-terms unknown",
+	}}
+	for i, test := range tests {
+		c.Logf("running test %d: %s", i, test.about)
+		ctx, err := cmdtesting.RunCommand(c, cmd.NewSuperCommand(), test.args...)
+		if test.err != "" {
+			c.Assert(err, gc.ErrorMatches, test.err)
+			continue
+		}
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(cmdtesting.Stdout(ctx), gc.Equals, test.stdout)
+	}
+
+	ctx, err := cmdtesting.RunCommand(c, cmd.NewSuperCommand(), "help", "term-ids")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cmdtesting.Stdout(ctx), jc.Contains, "[<owner>/]<name>[/<revision>]")
+
+	ctx, err = cmdtesting.RunCommand(c, cmd.NewSuperCommand(), "help")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cmdtesting.Stdout(ctx), jc.Contains, "--url <url>\n    host and port of the terms service")
+}
+
+func (s *commandSuite) TestTabular(c *gc.C) {
+	t := wireformat.TimeRFC3339(time.Date(2016, 1, 2, 4, 8, 16, 0, time.UTC))
+	s.client.setTerms([]wireformat.Term{{
+		Id:        "test-user/test-term/2",
+		Owner:     "test-user",
+		Name:      "test-term",
+		Revision:  2,
+		Title:     "Test Term",
+		CreatedOn: t,
+		Published: true,
+		Content:   testTermsAndConditions,
+	}, {
+		Id:        "test-user/a-term/10",
+		Owner:     "test-user",
+		Name:      "a-term",
+		Revision:  10,
+		CreatedOn: t,
+		Content:   testTermsAndConditions,
+	}})
+	tests := []struct {
+		about    string
+		command  func() jujucmd.Command
+		args     []string
+		terminal bool
+		err      string
+		stdout   string
+	}{{
+		about:   "show-term",
+		command: cmd.NewShowTermCommand,
+		args:    []string{"test-user/test-term/2", "--format", "tabular"},
+		stdout: `ID                     OWNER      NAME       REVISION  PUBLISHED  CREATED
+test-user/test-term/2  test-user  test-term  2         true       2016-01-02T04:08:16Z
+`,
+	}, {
+		about:    "show-term defaults to tabular on a terminal",
+		command:  cmd.NewShowTermCommand,
+		args:     []string{"test-user/test-term/2", "--columns", "id,title"},
+		terminal: true,
+		stdout: `ID                     TITLE
+test-user/test-term/2  Test Term
+`,
+	}, {
+		about:   "list-terms",
+		command: cmd.NewListTermsCommand,
+		args:    []string{"--format", "tabular"},
+		stdout: `ID                     OWNER      NAME       REVISION  PUBLISHED  CREATED
+test-user/test-term/2  test-user  test-term  2         true       2016-01-02T04:08:16Z
+test-user/a-term/10    test-user  a-term     10        false      2016-01-02T04:08:16Z
+`,
+	}, {
+		about:   "list-terms sorted",
+		command: cmd.NewListTermsCommand,
+		args:    []string{"--format", "tabular", "--columns", "name, revision", "--sort", "revision"},
+		stdout: `NAME       REVISION
+test-term  2
+a-term     10
+`,
+	}, {
+		about:   "list-terms sorted descending",
+		command: cmd.NewListTermsCommand,
+		args:    []string{"--format", "tabular", "--columns", "name", "--sort", "-name"},
+		stdout: `NAME
+test-term
+a-term
+`,
+	}, {
+		about:   "list-terms in yaml",
+		command: cmd.NewListTermsCommand,
+		args:    []string{},
+		stdout: `test-user:
+- id: test-user/test-term/2
+  owner: test-user
+  name: test-term
+  revision: 2
+  title: Test Term
+  createdon: "2016-01-02T04:08:16Z"
+  published: true
+- id: test-user/a-term/10
+  owner: test-user
+  name: a-term
+  revision: 10
+  createdon: "2016-01-02T04:08:16Z"
+  published: false
+`,
+	}, {
+		about:   "unknown column",
+		command: cmd.NewListTermsCommand,
+		args:    []string{"--format", "tabular", "--columns", "id,size"},
+		err:     `unknown column "size"`,
+	}, {
+		about:   "unknown sort column",
+		command: cmd.NewListTermsCommand,
+		args:    []string{"--format", "tabular", "--sort", "size"},
+		err:     `unknown sort column "size"`,
+	}}
+	for i, test := range tests {
+		c.Logf("running test %d: %s", i, test.about)
+		terminal := test.terminal
+		s.PatchValue(cmd.IsTerminal, func(*os.File) bool { return terminal })
+		ctx, err := cmdtesting.RunCommand(c, test.command(), test.args...)
+		if test.err != "" {
+			c.Assert(err, gc.ErrorMatches, test.err)
+			continue
+		}
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(cmdtesting.Stdout(ctx), gc.Equals, test.stdout)
+	}
+}
+
+func (s *commandSuite) TestServiceURL(c *gc.C) {
+	var (
+		mu    sync.Mutex
+		paths []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		paths = append(paths, req.Method+" "+req.URL.Path)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(req.URL.Path, "/publish"):
+			fmt.Fprint(w, `{"term-id":"owner/test-term/1"}`)
+		case req.Method == "POST":
+			fmt.Fprint(w, `{"term-id":"owner/test-term/1"}`)
+		case strings.HasSuffix(req.URL.Path, "/debug/status"):
+			fmt.Fprint(w, `{"checks":{}}`)
+		default:
+			fmt.Fprint(w, `[{"id":"owner/test-term/1","owner":"owner","name":"test-term","revision":1,"created-on":"2016-01-02T04:08:16Z"}]`)
+		}
+	}))
+	defer server.Close()
+	s.PatchValue(cmd.ClientNew, api.NewClient)
+	s.PatchEnvironment("JUJU_COOKIEFILE", filepath.Join(c.MkDir(), "cookies"))
+
+	tests := []struct {
+		about   string
+		command func() jujucmd.Command
+		args    []string
+		path    string
+	}{{
+		about:   "push-term",
+		command: cmd.NewPushTermCommand,
+		args:    []string{"test.txt", "owner/test-term", "--force"},
+		path:    "POST /v1/terms/owner/test-term",
+	}, {
+		about:   "show-term",
+		command: cmd.NewShowTermCommand,
+		args:    []string{"owner/test-term/1"},
+		path:    "GET /v1/terms/owner/test-term",
+	}, {
+		about:   "release-term",
+		command: cmd.NewReleaseTermCommand,
+		args:    []string{"owner/test-term/1"},
+		path:    "POST /v1/terms/owner/test-term/1/publish",
+	}, {
+		about:   "terms",
+		command: cmd.NewListTermsCommand,
+		args:    []string{},
+		path:    "GET /v1/g/test-user",
+	}, {
+		about:   "terms-status",
+		command: cmd.NewTermsStatusCommand,
+		args:    []string{},
+		path:    "GET /v1/debug/status",
+	}, {
+		about:   "charm-terms",
+		command: cmd.NewSuperCommand,
+		args:    []string{"show-term", "owner/test-term/1"},
+		path:    "GET /v1/terms/owner/test-term",
+	}}
+	for i, test := range tests {
+		c.Logf("running test %d: %s", i, test.about)
+		mu.Lock()
+		paths = nil
+		mu.Unlock()
+		args := append([]string{"--url", server.URL}, test.args...)
+		_, err := cmdtesting.RunCommand(c, test.command(), args...)
+		c.Assert(err, jc.ErrorIsNil)
+		mu.Lock()
+		c.Assert(paths, jc.DeepEquals, []string{test.path})
+		mu.Unlock()
+	}
+}
+
+type mockClient struct {
+	api.Client
+	jujutesting.Stub
+
+	lock          sync.Mutex
+	user          string
+	terms         []wireformat.Term
+	unsignedTerms []wireformat.Term
+	agreements    []wireformat.AgreementResponse
+	ownerErrs     map[string]error
+	delay         time.Duration
+	called        func()
+	inFlight      int
+	maxInFlight   int
+}
+
+func (c *mockClient) setTerms(t []wireformat.Term) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.terms = t
+}
+
+func (c *mockClient) setUnsignedTerms(t []wireformat.Term) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.unsignedTerms = t
+}
+
+func (c *mockClient) SaveTerm(_ context.Context, owner, name, content string) (string, error) {
+	c.AddCall("SaveTerm", owner, name, content)
+	if owner == "" {
+		return fmt.Sprintf("%s/1", name), nil
+	}
+	return fmt.Sprintf("%s/%s/1", owner, name), nil
+}
+
+// GetTerms returns matching Terms and Conditions documents.
+func (c *mockClient) GetTerm(_ context.Context, owner, name string, revision int) (*wireformat.Term, error) {
+	c.AddCall("GetTerm", owner, name, revision)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if len(c.terms) == 0 {
+		return nil, errors.NotFoundf("term")
+	}
+	t := c.terms[0]
+	return &t, nil
+}
+
+// SaveAgreement saves user's agreement to the specified
+// revision of the Terms and Conditions document.s
+func (c *mockClient) SaveAgreement(_ context.Context, agreements *wireformat.SaveAgreements) (*wireformat.SaveAgreementResponses, error) {
+	c.AddCall("SaveAgreement", agreements)
+	responses := make([]wireformat.AgreementResponse, len(agreements.Agreements))
+	for i, agreement := range agreements.Agreements {
+		responses[i] = wireformat.AgreementResponse{
+			User:     c.user,
+			Owner:    agreement.TermOwner,
+			Term:     agreement.TermName,
+			Revision: agreement.TermRevision,
+		}
+	}
+	return &wireformat.SaveAgreementResponses{Agreements: responses}, nil
+}
+
+func (c *mockClient) GetUsersAgreements(_ context.Context) ([]wireformat.AgreementResponse, error) {
+	c.MethodCall(c, "GetUsersAgreements")
+	return c.agreements, c.NextErr()
+}
+
+func (c *mockClient) GetUnsignedTerms(_ context.Context, terms *wireformat.CheckAgreementsRequest) ([]wireformat.GetTermsResponse, error) {
+	c.MethodCall(c, "GetUnsignedTerms", terms)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	r := make([]wireformat.GetTermsResponse, len(c.unsignedTerms))
+	for i, term := range c.unsignedTerms {
+		r[i].Owner = term.Owner
+		r[i].Name = term.Name
+		r[i].Title = term.Title
+		r[i].Revision = term.Revision
+		r[i].Content = term.Content
+	}
+	return r, c.NextErr()
+}
+
+func (c *mockClient) Publish(_ context.Context, owner, name string, revision int) (string, error) {
+	c.MethodCall(c, "Publish", owner, name, revision)
+	return "owner/name/1", nil
+}
+
+func (c *mockClient) GetTermsByOwner(ctx context.Context, owner string) ([]wireformat.Term, error) {
+	c.MethodCall(c, "GetTermsByOwner", owner)
+	c.lock.Lock()
+	c.inFlight++
+	if c.inFlight > c.maxInFlight {
+		c.maxInFlight = c.inFlight
+	}
+	terms, err, delay, called := c.terms, c.ownerErrs[owner], c.delay, c.called
+	c.lock.Unlock()
+	if called != nil {
+		called()
+	}
+	defer func() {
+		c.lock.Lock()
+		c.inFlight--
+		c.lock.Unlock()
+	}()
+	if err != nil {
+		return nil, err
+	}
+	select {
+	case <-time.After(delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return terms, c.NextErr()
+}
+
+// sortedCalls returns the recorded calls ordered by their arguments, for
+// checking calls made concurrently.
+func sortedCalls(calls []jujutesting.StubCall) []jujutesting.StubCall {
+	sort.Slice(calls, func(i, j int) bool {
+		return fmt.Sprint(calls[i].Args) < fmt.Sprint(calls[j].Args)
+	})
+	return calls
 }
 
 type mockIDMClient struct {
@@ -456,12 +2222,12 @@ type mockIDMClient struct {
 	groups   map[string][]string
 }
 
-func (c *mockIDMClient) WhoAmI() (string, error) {
+func (c *mockIDMClient) WhoAmI(_ context.Context) (string, error) {
 	c.MethodCall(c, "WhoAmI")
 	return c.username, c.NextErr()
 }
 
-func (c *mockIDMClient) Groups(username string) ([]string, error) {
+func (c *mockIDMClient) Groups(_ context.Context, username string) ([]string, error) {
 	c.MethodCall(c, "Groups", username)
 	groups, ok := c.groups[username]
 	if !ok {
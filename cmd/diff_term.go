@@ -0,0 +1,284 @@
+// Copyright 2020 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/juju/charm/v8"
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"github.com/juju/gnuflag"
+	"github.com/pmezard/go-difflib/difflib"
+
+	"github.com/juju/terms-client/api"
+	"github.com/juju/terms-client/api/wireformat"
+)
+
+const diffTermDoc = `
+diff-term is used to compare two revisions of a Terms and Conditions
+document. Changes to the title, the release status and the content of
+the document are shown.
+Examples
+diff-term owner/enterprise-plan/3 owner/enterprise-plan/4
+   shows the changes between revisions 3 and 4 of enterprise-plan.
+diff-term owner/enterprise-plan/4
+   shows the changes between revision 4 and the previous revision.
+diff-term owner/enterprise-plan
+   shows the changes between the latest and the previous revision.
+diff-term owner/enterprise-plan --format json
+   shows the changes in json format.
+`
+const diffTermPurpose = "shows changes between two revisions of a term"
+
+// NewDiffTermCommand returns a new command that can be used to compare
+// revisions of Terms and Conditions documents.
+func NewDiffTermCommand() cmd.Command {
+	return &diffTermCommand{}
+}
+
+type diffTermCommand struct {
+	baseCommand
+	out cmd.Output
+
+	FromID *charm.TermsId
+	ToID   *charm.TermsId
+}
+
+// SetFlags implements Command.SetFlags.
+func (c *diffTermCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "text", map[string]cmd.Formatter{
+		"text": formatTermDiffText,
+		"json": cmd.FormatJson,
+	})
+	c.baseCommand.SetFlags(f)
+}
+
+// Info implements Command.Info.
+func (c *diffTermCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "diff-term",
+		Args:    "<term id> [<term id>]",
+		Purpose: diffTermPurpose,
+		Doc:     diffTermDoc,
+	}
+}
+
+// Init reads and verifies the arguments.
+func (c *diffTermCommand) Init(args []string) error {
+	if len(args) < 1 {
+		return errors.New("missing arguments")
+	}
+	if len(args) > 2 {
+		return errors.Errorf("unknown arguments: %v", strings.Join(args[2:], ","))
+	}
+	ids := make([]*charm.TermsId, len(args))
+	for i, arg := range args {
+		id, err := charm.ParseTerm(arg)
+		if err != nil {
+			return errors.Annotate(err, "invalid term format")
+		}
+		ids[i] = id
+	}
+	c.FromID, c.ToID = nil, ids[0]
+	if len(ids) == 2 {
+		c.FromID, c.ToID = ids[0], ids[1]
+	}
+	return nil
+}
+
+// Description returns a one-line description of the command.
+func (c *diffTermCommand) Description() string {
+	return diffTermPurpose
+}
+
+// Run implements Command.Run.
+func (c *diffTermCommand) Run(ctx *cmd.Context) error {
+	bakeryClient, cleanup, err := c.NewClient(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer cleanup()
+	callCtx, cancel := c.newContext(ctx)
+	defer cancel()
+	termsClient, err := c.newTermsClient(bakeryClient)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	from, to, err := c.terms(callCtx, termsClient)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	err = c.out.Write(ctx, diffTerms(from, to))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+// terms returns the two revisions of the terms to compare.
+func (c *diffTermCommand) terms(ctx context.Context, client api.Client) (*wireformat.Term, *wireformat.Term, error) {
+	to, err := client.GetTerm(ctx, c.ToID.Owner, c.ToID.Name, c.ToID.Revision)
+	if err != nil {
+		return nil, nil, errors.Annotatef(err, "failed to retrieve term %q", c.ToID.String())
+	}
+	fromID := c.FromID
+	if fromID == nil {
+		if to.Revision <= 1 {
+			return nil, nil, errors.Errorf("term %q has no previous revision", termIDString(to))
+		}
+		fromID = &charm.TermsId{
+			Owner:    to.Owner,
+			Name:     to.Name,
+			Revision: to.Revision - 1,
+		}
+	}
+	from, err := client.GetTerm(ctx, fromID.Owner, fromID.Name, fromID.Revision)
+	if err != nil {
+		return nil, nil, errors.Annotatef(err, "failed to retrieve term %q", fromID.String())
+	}
+	return from, to, nil
+}
+
+// termDiff holds the differences between two revisions of a term.
+type termDiff struct {
+	From      string          `json:"from"`
+	To        string          `json:"to"`
+	Title     *valueChange    `json:"title,omitempty"`
+	Published *valueChange    `json:"published,omitempty"`
+	Content   []contentChange `json:"content,omitempty"`
+
+	unified string
+}
+
+// valueChange holds a changed value.
+type valueChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// contentChange holds a block of changed lines.
+type contentChange struct {
+	// Op holds the operation turning the old lines into the new
+	// ones: insert, delete or replace.
+	Op string `json:"op"`
+
+	// FromLine and ToLine hold the 1-based line numbers of the
+	// block in the old and new content.
+	FromLine int `json:"from-line"`
+	ToLine   int `json:"to-line"`
+
+	// Removed and Added hold the removed and added lines.
+	Removed []string `json:"removed,omitempty"`
+	Added   []string `json:"added,omitempty"`
+}
+
+// diffTerms returns the differences between the two terms.
+func diffTerms(from, to *wireformat.Term) *termDiff {
+	d := &termDiff{
+		From: termIDString(from),
+		To:   termIDString(to),
+	}
+	if from.Title != to.Title {
+		d.Title = &valueChange{From: from.Title, To: to.Title}
+	}
+	if from.Published != to.Published {
+		d.Published = &valueChange{From: from.Published, To: to.Published}
+	}
+	fromLines := splitLines(from.Content)
+	toLines := splitLines(to.Content)
+	matcher := difflib.NewMatcher(fromLines, toLines)
+	for _, op := range matcher.GetOpCodes() {
+		change := contentChange{
+			FromLine: op.I1 + 1,
+			ToLine:   op.J1 + 1,
+		}
+		switch op.Tag {
+		case 'r':
+			change.Op = "replace"
+		case 'd':
+			change.Op = "delete"
+		case 'i':
+			change.Op = "insert"
+		default:
+			continue
+		}
+		for _, line := range fromLines[op.I1:op.I2] {
+			change.Removed = append(change.Removed, strings.TrimSuffix(line, "\n"))
+		}
+		for _, line := range toLines[op.J1:op.J2] {
+			change.Added = append(change.Added, strings.TrimSuffix(line, "\n"))
+		}
+		d.Content = append(d.Content, change)
+	}
+	d.unified, _ = difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        fromLines,
+		B:        toLines,
+		FromFile: d.From,
+		ToFile:   d.To,
+		Context:  3,
+	})
+	return d
+}
+
+// lineCounts returns the number of content lines added and removed.
+func (d *termDiff) lineCounts() (added, removed int) {
+	for _, change := range d.Content {
+		added += len(change.Added)
+		removed += len(change.Removed)
+	}
+	return added, removed
+}
+
+// splitLines splits the content into lines, each terminated
+// by a newline.
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	} else {
+		lines[len(lines)-1] += "\n"
+	}
+	return lines
+}
+
+// formatTermDiffText writes the differences between two terms as
+// a list of changed attributes followed by a unified diff of the
+// content.
+func formatTermDiffText(writer io.Writer, value interface{}) error {
+	d, ok := value.(*termDiff)
+	if !ok {
+		return errors.Errorf("expected value of type %T, got %T", d, value)
+	}
+	var out strings.Builder
+	if d.Title != nil {
+		fmt.Fprintf(&out, "title: %q -> %q\n", d.Title.From, d.Title.To)
+	}
+	if d.Published != nil {
+		fmt.Fprintf(&out, "published: %v -> %v\n", d.Published.From, d.Published.To)
+	}
+	out.WriteString(d.unified)
+	if out.Len() == 0 {
+		fmt.Fprintf(&out, "no differences between %s and %s\n", d.From, d.To)
+	}
+	_, err := io.WriteString(writer, strings.TrimSuffix(out.String(), "\n"))
+	return errors.Trace(err)
+}
+
+// termIDString returns the fully qualified id of the term.
+func termIDString(t *wireformat.Term) string {
+	id := charm.TermsId{
+		Owner:    t.Owner,
+		Name:     t.Name,
+		Revision: t.Revision,
+	}
+	return id.String()
+}
@@ -0,0 +1,161 @@
+// Copyright 2020 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"time"
+
+	"github.com/juju/charm/v8"
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"github.com/juju/gnuflag"
+
+	"github.com/juju/terms-client/api"
+	"github.com/juju/terms-client/api/wireformat"
+)
+
+const exportAgreementsDoc = `
+export-agreements writes a bundle holding the agreements made by the
+user together with the content of the agreed terms. The bundle can be
+used to verify agreements where the terms service is not reachable,
+for example with check-terms --bundle. Additional terms to include in
+the bundle may be given as arguments.
+Examples
+export-agreements -o agreements.json
+   writes the user's agreements to agreements.json.
+export-agreements -o agreements.json owner/enterprise-plan
+   also includes the latest revision of owner/enterprise-plan, whether
+   or not the user agreed to it.
+`
+const exportAgreementsPurpose = "export the user's agreements for offline use"
+
+// NewExportAgreementsCommand returns a new command that can be used
+// to export the user's agreements to a bundle.
+func NewExportAgreementsCommand() cmd.Command {
+	return &exportAgreementsCommand{}
+}
+
+type exportAgreementsCommand struct {
+	baseCommand
+
+	TermIDs    []*charm.TermsId
+	OutputFile string
+}
+
+// SetFlags implements Command.SetFlags.
+func (c *exportAgreementsCommand) SetFlags(f *gnuflag.FlagSet) {
+	f.StringVar(&c.OutputFile, "o", "", "file the bundle is written to (default stdout)")
+	f.StringVar(&c.OutputFile, "output", "", "")
+	c.baseCommand.SetFlags(f)
+}
+
+// Info implements Command.Info.
+func (c *exportAgreementsCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "export-agreements",
+		Args:    "[<term id> ...]",
+		Purpose: exportAgreementsPurpose,
+		Doc:     exportAgreementsDoc,
+	}
+}
+
+// Init reads and verifies the arguments.
+func (c *exportAgreementsCommand) Init(args []string) error {
+	c.TermIDs = nil
+	for _, arg := range args {
+		termID, err := charm.ParseTerm(arg)
+		if err != nil {
+			return errors.Annotate(err, "invalid term format")
+		}
+		c.TermIDs = append(c.TermIDs, termID)
+	}
+	return nil
+}
+
+// Description returns a one-line description of the command.
+func (c *exportAgreementsCommand) Description() string {
+	return exportAgreementsPurpose
+}
+
+// Run implements Command.Run.
+func (c *exportAgreementsCommand) Run(ctx *cmd.Context) error {
+	bakeryClient, cleanup, err := c.NewClient(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer cleanup()
+	callCtx, cancel := c.newContext(ctx)
+	defer cancel()
+	termsClient, err := c.newTermsClient(bakeryClient)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	bundle, err := c.bundle(callCtx, termsClient)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	var buf bytes.Buffer
+	if err := bundle.Write(&buf); err != nil {
+		return errors.Trace(err)
+	}
+	if c.OutputFile == "" {
+		_, err = ctx.Stdout.Write(buf.Bytes())
+		return errors.Trace(err)
+	}
+	if err := ioutil.WriteFile(ctx.AbsPath(c.OutputFile), buf.Bytes(), 0644); err != nil {
+		return errors.Annotate(err, "failed to write agreement bundle")
+	}
+	ctx.Infof("Exported %d agreement(s) and %d term(s) to %s", len(bundle.Agreements), len(bundle.Terms), c.OutputFile)
+	return nil
+}
+
+// bundle returns a bundle holding the user's agreements, the agreed
+// terms and the terms specified on the command line.
+func (c *exportAgreementsCommand) bundle(ctx context.Context, client api.Client) (*api.Bundle, error) {
+	agreements, err := client.GetUsersAgreements(ctx)
+	if err != nil {
+		return nil, errors.Annotate(err, "failed to get user agreements")
+	}
+	bundle := &api.Bundle{
+		Version:    api.BundleVersion,
+		CreatedOn:  time.Now().UTC().Truncate(time.Second),
+		Terms:      []wireformat.Term{},
+		Agreements: agreements,
+	}
+	if len(agreements) > 0 {
+		bundle.User = agreements[0].User
+	}
+
+	termIDs := make([]*charm.TermsId, 0, len(agreements)+len(c.TermIDs))
+	for _, a := range agreements {
+		termIDs = append(termIDs, &charm.TermsId{
+			Owner:    a.Owner,
+			Name:     a.Term,
+			Revision: a.Revision,
+		})
+	}
+	termIDs = append(termIDs, c.TermIDs...)
+
+	seen := make(map[string]bool)
+	for _, termID := range termIDs {
+		if termID.Revision != 0 && seen[termID.String()] {
+			continue
+		}
+		t, err := client.GetTerm(ctx, termID.Owner, termID.Name, termID.Revision)
+		if err != nil {
+			return nil, errors.Annotatef(err, "failed to retrieve term %q", termID.String())
+		}
+		id := termIDString(t)
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		bundle.Terms = append(bundle.Terms, *t)
+	}
+	return bundle, nil
+}
@@ -4,9 +4,12 @@
 package cmd
 
 var (
-	ClientNew    = &clientNew
-	ReadFile     = &readFile
-	NewIDMClient = &newIDMClient
+	ClientNew     = &clientNew
+	ReadFile      = &readFile
+	NewIDMClient  = &newIDMClient
+	IsTerminal    = &isTerminal
+	TermsCacheDir = &termsCacheDir
+	ConfigFile    = &configFile
 )
 
 // BaseCommand type is exported for test purposes.
@@ -19,3 +22,8 @@ type BaseCommand struct {
 func NewBaseCommand() BaseCommand {
 	return BaseCommand{&baseCommand{}}
 }
+
+// CookieFile returns the path of the cookie file used by the command.
+func (c BaseCommand) CookieFile() string {
+	return cookieFile(c.profile)
+}
@@ -0,0 +1,132 @@
+// Copyright 2020 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"github.com/juju/gnuflag"
+
+	"github.com/juju/terms-client/lint"
+)
+
+const lintTermDoc = `
+lint-term checks the content of a Terms and Conditions document for
+common mistakes before it is pushed: invalid UTF-8, documents that are
+empty or too large, trailing whitespace, a missing title, broken
+Markdown links and placeholder text such as TODO or XXX.
+The command fails if any errors are found; warnings are only reported.
+Examples
+lint-term text.md
+   checks the document in text.md.
+lint-term --format json text.md
+   reports the problems found in json format.
+`
+const lintTermPurpose = "check a Terms and Conditions document for common mistakes"
+
+// NewLintTermCommand returns a new command that can be used to
+// check Terms and Conditions documents before pushing them.
+func NewLintTermCommand() cmd.Command {
+	return &lintTermCommand{}
+}
+
+type lintTermCommand struct {
+	cmd.CommandBase
+	out cmd.Output
+
+	TermFilename string
+	MaxSize      int
+}
+
+// SetFlags implements Command.SetFlags.
+func (c *lintTermCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "text", map[string]cmd.Formatter{
+		"text": formatFindings,
+		"yaml": cmd.FormatYaml,
+		"json": cmd.FormatJson,
+	})
+	f.IntVar(&c.MaxSize, "max-size", lint.DefaultMaxSize, "maximum size of the document in bytes")
+}
+
+// Info implements Command.Info.
+func (c *lintTermCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "lint-term",
+		Args:    "<filename>",
+		Purpose: lintTermPurpose,
+		Doc:     lintTermDoc,
+	}
+}
+
+// Init reads and verifies the arguments.
+func (c *lintTermCommand) Init(args []string) error {
+	if len(args) < 1 {
+		return errors.New("missing arguments")
+	}
+	fn, args := args[0], args[1:]
+	if err := cmd.CheckEmpty(args); err != nil {
+		return errors.Errorf("unknown arguments: %v", strings.Join(args, ","))
+	}
+	if c.MaxSize < 1 {
+		return errors.Errorf("invalid --max-size value %d", c.MaxSize)
+	}
+	c.TermFilename = fn
+	return nil
+}
+
+// Description returns a one-line description of the command.
+func (c *lintTermCommand) Description() string {
+	return lintTermPurpose
+}
+
+// Run implements Command.Run.
+func (c *lintTermCommand) Run(ctx *cmd.Context) error {
+	data, err := readFile(c.TermFilename)
+	if err != nil {
+		return errors.Annotatef(err, "could not read contents of %q", c.TermFilename)
+	}
+	findings := lint.Lint(data, lint.Options{MaxSize: c.MaxSize})
+	if len(findings) == 0 {
+		ctx.Infof("No problems found.")
+		return nil
+	}
+	if err := c.out.Write(ctx, findings); err != nil {
+		return errors.Trace(err)
+	}
+	return lintError(findings)
+}
+
+// formatFindings writes the lint findings one per line.
+func formatFindings(w io.Writer, value interface{}) error {
+	findings, ok := value.([]lint.Finding)
+	if !ok {
+		return errors.Errorf("expected value of type %T, got %T", findings, value)
+	}
+	for i, f := range findings {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprint(w, f)
+	}
+	return nil
+}
+
+// lintError returns an error reporting the number of lint errors
+// found, or nil if the findings contain no error.
+func lintError(findings []lint.Finding) error {
+	var n int
+	for _, f := range findings {
+		if f.Severity == lint.Error {
+			n++
+		}
+	}
+	if n == 0 {
+		return nil
+	}
+	return errors.Errorf("%d lint error(s) found", n)
+}
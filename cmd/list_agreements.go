@@ -0,0 +1,94 @@
+// Copyright 2020 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package cmd
+
+import (
+	"strings"
+
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"github.com/juju/gnuflag"
+)
+
+const listAgreementsDoc = `
+agreements shows a list of Terms and Conditions documents the user
+has agreed to.
+Examples
+agreements --format json
+   lists the user's agreements in json format.
+`
+const listAgreementsPurpose = "list agreements made by the current user"
+
+// NewListAgreementsCommand returns a new command that can be used
+// to list agreements the user has made.
+func NewListAgreementsCommand() cmd.Command {
+	return &listAgreementsCommand{
+		table: newAgreementTable("term", "created"),
+	}
+}
+
+type listAgreementsCommand struct {
+	baseCommand
+	out   cmd.Output
+	table *table
+}
+
+// SetFlags implements Command.SetFlags.
+func (c *listAgreementsCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, defaultFormat("yaml"), c.table.formatters())
+	c.table.SetFlags(f)
+	c.baseCommand.SetFlags(f)
+}
+
+// Info implements Command.Info.
+func (c *listAgreementsCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "agreements",
+		Purpose: listAgreementsPurpose,
+		Doc:     listAgreementsDoc,
+		Aliases: []string{"list-agreements"},
+	}
+}
+
+// Init reads and verifies the arguments.
+func (c *listAgreementsCommand) Init(args []string) error {
+	if err := cmd.CheckEmpty(args); err != nil {
+		return errors.Errorf("unknown arguments: %v", strings.Join(args, ","))
+	}
+	return nil
+}
+
+// Description returns a one-line description of the command.
+func (c *listAgreementsCommand) Description() string {
+	return listAgreementsPurpose
+}
+
+// Run implements Command.Run.
+func (c *listAgreementsCommand) Run(ctx *cmd.Context) error {
+	bakeryClient, cleanup, err := c.NewClient(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer cleanup()
+	callCtx, cancel := c.newContext(ctx)
+	defer cancel()
+	termsClient, err := c.newTermsClient(bakeryClient)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	agreements, err := termsClient.GetUsersAgreements(callCtx)
+	if err != nil {
+		return errors.Annotate(err, "failed to list user agreements")
+	}
+	if len(agreements) == 0 {
+		ctx.Infof("No agreements to display.")
+		return nil
+	}
+	err = c.out.Write(ctx, agreements)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return nil
+}
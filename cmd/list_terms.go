@@ -5,12 +5,12 @@ package cmd
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"net/http"
 	"os"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/juju/cmd"
 	"github.com/juju/errors"
@@ -18,42 +18,74 @@ import (
 	"gopkg.in/macaroon-bakery.v2/httpbakery"
 
 	"github.com/juju/terms-client/api"
+	"github.com/juju/terms-client/api/wireformat"
+	"github.com/juju/terms-client/idm"
 )
 
 const (
 	listTermsDoc = `
-list-terms shows a list of "Terms and Conditions" documents owned by you
-or one of the public groups you belong to.
+list-terms shows the "Terms and Conditions" documents owned by you
+or one of the public groups you belong to, grouped by owner.
 Examples
 list-terms --groups test-group1,test-group2
    lists the terms owned by you or one of the public groups you belong to or
    to one of the test-group1 and test-group2, which you must belong to, but may
    not be public.
+list-terms --owner test-group1 --published --since 2020-01-31
+   lists the released terms owned by test-group1 created since
+   January 31st 2020.
+
+The terms of each owner are fetched concurrently, with at most --parallel
+requests in flight at once. By default the first failure aborts the
+command; with --keep-going the failures are reported and the terms of the
+remaining owners are still listed.
 `
 	listTermsPurpose = "list terms owned by the current user"
 
 	defaultIDMURL = "https://api.jujucharms.com/identity/v1"
+
+	defaultParallel = 4
 )
 
 // NewListTermsCommand returns a new command that can be used
 // to list owned Terms and Conditions documents.
 func NewListTermsCommand() cmd.Command {
-	return &listTermsCommand{}
+	return &listTermsCommand{
+		table: newTermTable("id", "owner", "name", "revision", "published", "created"),
+	}
 }
 
 type listTermsCommand struct {
 	baseCommand
-	out cmd.Output
+	out   cmd.Output
+	table *table
 
 	IdentityURL string
 	GroupList   string
+	OwnerList   string
+	ShowContent bool
+	Published   bool
+	Unpublished bool
+	SinceStr    string
+	Parallel    int
+	KeepGoing   bool
+
+	since time.Time
 }
 
 // SetFlags implements Command.SetFlags.
 func (c *listTermsCommand) SetFlags(f *gnuflag.FlagSet) {
-	c.out.AddFlags(f, "yaml", cmd.DefaultFormatters.Formatters())
+	c.out.AddFlags(f, defaultFormat("yaml"), c.table.formatters())
+	c.table.SetFlags(f)
 	f.StringVar(&c.GroupList, "groups", "", "a comma separated list of additional groups")
-	f.StringVar(&c.IdentityURL, "identity-url", idmBaseURL(), "host and port of the identity service")
+	f.StringVar(&c.OwnerList, "owner", "", "a comma separated list of owners whose terms are listed instead of the user's groups")
+	f.BoolVar(&c.ShowContent, "content", false, "include the content of the terms")
+	f.BoolVar(&c.Published, "published", false, "list only released terms")
+	f.BoolVar(&c.Unpublished, "unpublished", false, "list only terms that have not been released")
+	f.StringVar(&c.SinceStr, "since", "", "list only terms created at or after the specified date (YYYY-MM-DD or RFC3339)")
+	f.IntVar(&c.Parallel, "parallel", defaultParallel, "maximum number of owners queried concurrently")
+	f.BoolVar(&c.KeepGoing, "keep-going", false, "report owners whose terms cannot be fetched instead of failing")
+	f.StringVar(&c.IdentityURL, "identity-url", "", "host and port of the identity service")
 	c.baseCommand.SetFlags(f)
 }
 
@@ -71,6 +103,20 @@ func (c *listTermsCommand) Init(args []string) error {
 	if err := cmd.CheckEmpty(args); err != nil {
 		return errors.Errorf("unknown arguments: %v", strings.Join(args[1:], ","))
 	}
+	if c.Published && c.Unpublished {
+		return errors.New("cannot specify both --published and --unpublished")
+	}
+	if c.Parallel < 1 {
+		return errors.Errorf("invalid --parallel value %d, must be at least 1", c.Parallel)
+	}
+	c.since = time.Time{}
+	if c.SinceStr != "" {
+		since, err := parseDate(c.SinceStr)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		c.since = since
+	}
 	return nil
 }
 
@@ -86,135 +132,224 @@ func (c *listTermsCommand) Run(ctx *cmd.Context) error {
 		return errors.Trace(err)
 	}
 	defer cleanup()
-	termsClient, err := clientNew(
-		api.HTTPClient(bakeryClient),
-	)
+	callCtx, cancel := c.newContext(ctx)
+	defer cancel()
+	termsClient, err := c.newTermsClient(bakeryClient)
 	if err != nil {
 		return errors.Trace(err)
 	}
 
-	idmClient := newIDMClient(c.IdentityURL, bakeryClient)
-
-	// first we perform a whoami request
-	username, err := idmClient.WhoAmI()
-	if err != nil {
-		return errors.Trace(err)
-	}
-
-	// the we get the public groups the user belongs to
-	userGroups, err := idmClient.Groups(username)
-	if err != nil {
-		return errors.Trace(err)
+	owners := splitList(c.OwnerList)
+	if len(owners) == 0 {
+		identityURL := firstNonEmpty(c.IdentityURL, os.Getenv("JUJU_IDENTITY"), c.profile.IdentityURL, defaultIDMURL)
+		owners, err = c.groups(callCtx, newIDMClient(identityURL, bakeryClient))
+		if err != nil {
+			return errors.Trace(err)
+		}
+	} else {
+		owners = uniqueSorted(owners)
 	}
 
-	groups := make(map[string]bool, len(userGroups))
-	for _, groupName := range userGroups {
-		groups[groupName] = true
-	}
-	if c.GroupList != "" {
-		privateGroups := strings.Split(c.GroupList, ",")
-		for _, groupName := range privateGroups {
-			g := strings.TrimSpace(groupName)
-			groups[g] = true
+	results := c.fetchTerms(callCtx, termsClient, owners)
+	if !c.KeepGoing {
+		if err := firstError(results); err != nil {
+			return errors.Trace(err)
 		}
 	}
-	groupSlice := make([]string, len(groups))
-	i := 0
-	for g, _ := range groups {
-		groupSlice[i] = g
-		i++
-	}
-	sort.Strings(groupSlice)
 
-	terms := []string{}
-	for _, groupName := range groupSlice {
-		groupTerms, err := termsClient.GetTermsByOwner(context.Background(), groupName)
-		if err != nil {
-			return errors.Trace(err)
+	terms := []wireformat.Term{}
+	termsByOwner := make(map[string][]wireformat.Term)
+	var failed int
+	for i, result := range results {
+		if result.err != nil {
+			fmt.Fprintf(ctx.Stderr, "cannot list terms owned by %q: %v\n", owners[i], result.err)
+			failed++
+			continue
 		}
-		for _, t := range groupTerms {
-			terms = append(terms, t.Id)
+		for _, t := range result.terms {
+			if !c.matches(t) {
+				continue
+			}
+			if !c.ShowContent {
+				t.Content = ""
+			}
+			terms = append(terms, t)
+			termsByOwner[owners[i]] = append(termsByOwner[owners[i]], t)
 		}
 	}
 
-	err = c.out.Write(ctx, terms)
+	if c.out.Name() == "tabular" {
+		err = c.out.Write(ctx, terms)
+	} else {
+		err = c.out.Write(ctx, termsByOwner)
+	}
 	if err != nil {
 		return errors.Trace(err)
 	}
+	if failed > 0 {
+		return errors.Errorf("failed to list terms for %d of %d owner(s)", failed, len(owners))
+	}
 	return nil
 }
 
-var newIDMClient = func(idmURL string, client *httpbakery.Client) IDMClient {
-	return &idmClient{
-		idmURL: idmURL,
-		client: client,
-	}
+// ownerTerms holds the result of fetching the terms of a single owner.
+type ownerTerms struct {
+	terms []wireformat.Term
+	err   error
 }
 
-// IDMClient defines the interface of an identity client used by the list-terms
-// command.
-type IDMClient interface {
-	// WhoAmI returns the user's username as declared by the identity managed.
-	WhoAmI() (string, error)
-	// Groups returns public grous the specified user belongs to.
-	Groups(username string) ([]string, error)
-}
+// fetchTerms fetches the terms of all the specified owners running at most
+// c.Parallel requests concurrently. The results are returned in the same
+// order as the owners, regardless of the order in which the requests
+// complete. Unless c.KeepGoing is set, the first failure cancels all
+// outstanding requests.
+func (c *listTermsCommand) fetchTerms(ctx context.Context, client api.Client, owners []string) []ownerTerms {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-type idmClient struct {
-	idmURL string
-	client *httpbakery.Client
+	results := make([]ownerTerms, len(owners))
+	sem := make(chan struct{}, c.Parallel)
+	var wg sync.WaitGroup
+	for i, owner := range owners {
+		wg.Add(1)
+		go func(i int, owner string) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				results[i].err = errors.Trace(ctx.Err())
+				return
+			}
+			if err := ctx.Err(); err != nil {
+				results[i].err = errors.Trace(err)
+				return
+			}
+			terms, err := client.GetTermsByOwner(ctx, owner)
+			if err != nil {
+				results[i].err = errors.Trace(err)
+				if !c.KeepGoing {
+					cancel()
+				}
+				return
+			}
+			results[i].terms = terms
+		}(i, owner)
+	}
+	wg.Wait()
+	return results
 }
 
-// WhoAmI returns the user's username as declared by the identity managed.
-func (c *idmClient) WhoAmI() (string, error) {
-	fail := func(err error) (string, error) {
-		return "", err
+// firstError returns the first error that caused the fetch to be
+// aborted, ignoring the errors of the requests canceled as a consequence.
+func firstError(results []ownerTerms) error {
+	var canceled error
+	for _, result := range results {
+		if result.err == nil {
+			continue
+		}
+		if errors.Cause(result.err) != context.Canceled {
+			return result.err
+		}
+		if canceled == nil {
+			canceled = result.err
+		}
 	}
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s/whoami", c.idmURL), nil)
+	return canceled
+}
+
+// groups returns the sorted names of the user and all the groups the
+// user belongs to, including the groups specified on the command line.
+func (c *listTermsCommand) groups(ctx context.Context, idmClient IDMClient) ([]string, error) {
+	// first we perform a whoami request
+	username, err := idmClient.WhoAmI(ctx)
 	if err != nil {
-		return fail(errors.Trace(err))
+		return nil, errors.Trace(err)
 	}
-	response, err := c.client.Do(req)
+
+	// the we get the public groups the user belongs to
+	userGroups, err := idmClient.Groups(ctx, username)
 	if err != nil {
-		return fail(errors.Trace(err))
+		return nil, errors.Trace(err)
 	}
-	var username struct {
-		User string `json:"user"`
+
+	groups := make(map[string]bool, len(userGroups))
+	for _, groupName := range userGroups {
+		groups[groupName] = true
 	}
-	decoder := json.NewDecoder(response.Body)
-	err = decoder.Decode(&username)
-	if err != nil {
-		return fail(errors.Trace(err))
+	for _, groupName := range splitList(c.GroupList) {
+		groups[groupName] = true
+	}
+	groupSlice := make([]string, 0, len(groups))
+	for g := range groups {
+		groupSlice = append(groupSlice, g)
 	}
-	return username.User, nil
+	sort.Strings(groupSlice)
+	return groupSlice, nil
 }
 
-// Groups returns public grous the specified user belongs to.
-func (c *idmClient) Groups(username string) ([]string, error) {
-	fail := func(err error) ([]string, error) {
-		return nil, err
+// matches reports whether the term matches the filters specified
+// on the command line.
+func (c *listTermsCommand) matches(t wireformat.Term) bool {
+	if c.Published && !t.Published {
+		return false
 	}
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s/u/%s/groups", c.idmURL, username), nil)
-	if err != nil {
-		return fail(errors.Trace(err))
+	if c.Unpublished && t.Published {
+		return false
 	}
-	response, err := c.client.Do(req)
-	if err != nil {
-		return fail(errors.Trace(err))
+	if !c.since.IsZero() && time.Time(t.CreatedOn).Before(c.since) {
+		return false
 	}
-	var groups []string
-	decoder := json.NewDecoder(response.Body)
-	err = decoder.Decode(&groups)
-	if err != nil {
-		return fail(errors.Trace(err))
+	return true
+}
+
+// splitList splits a comma separated list, ignoring empty elements.
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// uniqueSorted returns the sorted list of distinct items.
+func uniqueSorted(items []string) []string {
+	seen := make(map[string]bool, len(items))
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		if !seen[item] {
+			seen[item] = true
+			result = append(result, item)
+		}
 	}
-	return groups, nil
+	sort.Strings(result)
+	return result
 }
 
-func idmBaseURL() string {
-	baseURL := defaultIDMURL
-	if idmURL := os.Getenv("JUJU_IDENTITY"); idmURL != "" {
-		baseURL = idmURL
+// parseDate parses a date specified either as YYYY-MM-DD or in the
+// RFC3339 format.
+func parseDate(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, errors.Errorf("invalid date %q, expected YYYY-MM-DD or RFC3339", value)
 	}
-	return baseURL
+	return t, nil
+}
+
+var newIDMClient = func(idmURL string, client *httpbakery.Client) IDMClient {
+	return idm.NewClient(idmURL, client)
+}
+
+// IDMClient defines the interface of an identity client used by the list-terms
+// command.
+type IDMClient interface {
+	// WhoAmI returns the user's username as declared by the identity managed.
+	WhoAmI(ctx context.Context) (string, error)
+	// Groups returns public grous the specified user belongs to.
+	Groups(ctx context.Context, username string) ([]string, error)
 }
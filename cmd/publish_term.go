@@ -18,7 +18,11 @@ import (
 const publishTermDoc = `
 release-term is used to release a Terms and Conditions document.
 Examples
-release-term me/my-terms
+release-term me/my-terms/2
+   releases revision 2 of me/my-terms.
+release-term --dry-run me/my-terms/2
+   reports whether revision 2 of me/my-terms is already released,
+   without releasing it.
 `
 const publishTermPurpose = "releases the given terms document"
 
@@ -26,7 +30,9 @@ const publishTermPurpose = "releases the given terms document"
 // used to publish existing owner terms
 // Conditions documents.
 func NewReleaseTermCommand() cmd.Command {
-	return &releaseTermCommand{}
+	return &releaseTermCommand{
+		baseCommand: baseCommand{uncached: true},
+	}
 }
 
 type releaseTermCommand struct {
@@ -34,11 +40,13 @@ type releaseTermCommand struct {
 	out cmd.Output
 
 	TermID string
+	DryRun bool
 }
 
 // SetFlags implements Command.SetFlags.
 func (c *releaseTermCommand) SetFlags(f *gnuflag.FlagSet) {
 	c.out.AddFlags(f, "yaml", cmd.DefaultFormatters.Formatters())
+	f.BoolVar(&c.DryRun, "dry-run", false, "report what would be released without releasing it")
 	c.baseCommand.SetFlags(f)
 }
 
@@ -76,9 +84,9 @@ func (c *releaseTermCommand) Run(ctx *cmd.Context) error {
 		return errors.Trace(err)
 	}
 	defer cleanup()
-	termsClient, err := clientNew(
-		api.HTTPClient(bakeryClient),
-	)
+	callCtx, cancel := c.newContext(ctx)
+	defer cancel()
+	termsClient, err := c.newTermsClient(bakeryClient)
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -95,8 +103,20 @@ func (c *releaseTermCommand) Run(ctx *cmd.Context) error {
 		return errors.New("must specify a term revision")
 	}
 
+	if c.DryRun {
+		plan, err := planRelease(callCtx, termsClient, termsId)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if err := c.out.Write(ctx, plan); err != nil {
+			return errors.Trace(err)
+		}
+		ctx.Infof("Dry run: nothing was released.")
+		return nil
+	}
+
 	response, err := termsClient.Publish(
-		context.Background(),
+		callCtx,
 		termsId.Owner,
 		termsId.Name,
 		termsId.Revision,
@@ -111,3 +131,34 @@ func (c *releaseTermCommand) Run(ctx *cmd.Context) error {
 	}
 	return nil
 }
+
+// releasePlan describes the outcome of releasing a term revision.
+type releasePlan struct {
+	// Term holds the id of the revision to be released.
+	Term string `json:"term" yaml:"term"`
+
+	// AlreadyReleased reports whether the revision has already
+	// been released.
+	AlreadyReleased bool `json:"already-released" yaml:"already-released"`
+
+	// Latest holds the id of the latest revision of the term.
+	Latest string `json:"latest" yaml:"latest"`
+}
+
+// planRelease returns what releasing the specified term revision
+// would do, without releasing it.
+func planRelease(ctx context.Context, client api.Client, termID *charm.TermsId) (*releasePlan, error) {
+	t, err := client.GetTerm(ctx, termID.Owner, termID.Name, termID.Revision)
+	if err != nil {
+		return nil, errors.Annotatef(err, "failed to retrieve term %q", termID.String())
+	}
+	latest, err := client.GetTerm(ctx, termID.Owner, termID.Name, 0)
+	if err != nil {
+		return nil, errors.Annotatef(err, "failed to retrieve the latest revision of %q", termID.String())
+	}
+	return &releasePlan{
+		Term:            termIDString(t),
+		AlreadyReleased: t.Published,
+		Latest:          termIDString(latest),
+	}, nil
+}
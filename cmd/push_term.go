@@ -5,6 +5,8 @@ package cmd
 
 import (
 	"context"
+	"crypto/sha256"
+	"fmt"
 	"strings"
 
 	"github.com/juju/charm/v8"
@@ -13,21 +15,33 @@ import (
 	"github.com/juju/gnuflag"
 
 	"github.com/juju/terms-client/api"
-)
-
-var (
-	clientNew = func(options ...api.ClientOption) (api.Client, error) {
-		return api.NewClient(options...)
-	}
+	"github.com/juju/terms-client/api/wireformat"
+	"github.com/juju/terms-client/lint"
 )
 
 const pushTermDoc = `
 push-term is used to create a new Terms and Conditions document.
+If the content is the same as the content of the latest revision,
+ignoring differences in line endings, no new revision is created and
+the id of the latest revision is returned, unless --force is specified.
 Examples
 push-term text.txt user/enterprise-plan
    creates a new Terms and Conditions with the content from 
    file text.txt and the name enterprise-plan and 
    returns the revision of the created document.
+push-term --lint text.md user/enterprise-plan
+   checks the content of text.md as lint-term does before
+   pushing it and does not push it if any errors are found.
+push-term --release text.md user/enterprise-plan
+   creates a new revision of user/enterprise-plan and releases it.
+push-term --dry-run text.md user/enterprise-plan
+   reports the revision that would be created, if any, and how its
+   content differs from the latest revision, without pushing anything.
+push-term --set company="Acme Corp" --values acme.yaml terms.md.tmpl acme/enterprise-plan
+   renders terms.md.tmpl as a Go text/template template with the
+   values held in acme.yaml and the company value, and pushes the
+   rendered content. Use render-term to review the rendered content
+   before pushing it.
 `
 const pushTermPurpose = "create new Terms and Conditions document (revision)"
 
@@ -35,21 +49,33 @@ const pushTermPurpose = "create new Terms and Conditions document (revision)"
 // used to create new (revisions) of Terms and
 // Conditions documents.
 func NewPushTermCommand() cmd.Command {
-	return &pushTermCommand{}
+	return &pushTermCommand{
+		baseCommand: baseCommand{uncached: true},
+	}
 }
 
 // pushTermCommand creates a new Terms and Conditions document.
 type pushTermCommand struct {
 	baseCommand
-	out cmd.Output
+	out      cmd.Output
+	template templateFlags
 
 	TermID       string
 	TermFilename string
+	Lint         bool
+	DryRun       bool
+	Force        bool
+	Release      bool
 }
 
 // SetFlags implements Command.SetFlags.
 func (c *pushTermCommand) SetFlags(f *gnuflag.FlagSet) {
 	c.out.AddFlags(f, "yaml", cmd.DefaultFormatters.Formatters())
+	f.BoolVar(&c.Lint, "lint", false, "check the content of the document before pushing it")
+	f.BoolVar(&c.DryRun, "dry-run", false, "report what would be pushed without pushing it")
+	f.BoolVar(&c.Force, "force", false, "push a new revision even if the content is unchanged")
+	f.BoolVar(&c.Release, "release", false, "release the pushed revision")
+	c.template.SetFlags(f)
 	c.baseCommand.SetFlags(f)
 }
 
@@ -101,21 +127,77 @@ func (c *pushTermCommand) Run(ctx *cmd.Context) error {
 	if err != nil {
 		return errors.Annotatef(err, "could not read contents of %q", c.TermFilename)
 	}
+	if c.template.enabled() {
+		data, err = c.template.render(c.TermFilename, data)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if c.Lint {
+		findings := lint.Lint(data, lint.Options{})
+		if len(findings) > 0 {
+			formatFindings(ctx.Stderr, findings)
+			fmt.Fprintln(ctx.Stderr)
+		}
+		if err := lintError(findings); err != nil {
+			return errors.Annotatef(err, "not pushing %q", c.TermFilename)
+		}
+	}
 
 	bakeryClient, cleanup, err := c.NewClient(ctx)
 	if err != nil {
 		return errors.Trace(err)
 	}
 	defer cleanup()
-	termsClient, err := clientNew(
-		api.HTTPClient(bakeryClient),
-	)
+	callCtx, cancel := c.newContext(ctx)
+	defer cancel()
+	termsClient, err := c.newTermsClient(bakeryClient)
 	if err != nil {
 		return errors.Trace(err)
 	}
 
+	if c.DryRun {
+		plan, err := planPush(callCtx, termsClient, termid, string(data), c.Force)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		plan.Release = c.Release
+		if err := c.out.Write(ctx, plan); err != nil {
+			return errors.Trace(err)
+		}
+		ctx.Infof("Dry run: nothing was pushed.")
+		return nil
+	}
+	if !c.Force {
+		plan, err := planPush(callCtx, termsClient, termid, string(data), false)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if plan.Unchanged {
+			ctx.Infof("Content unchanged since %s, not pushing a new revision.", plan.Previous)
+			if !c.Release {
+				return errors.Trace(c.out.Write(ctx, plan.Previous))
+			}
+			result, err := releaseLatest(callCtx, termsClient, plan.latest)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			return errors.Trace(c.out.Write(ctx, result))
+		}
+	}
+
+	if c.Release {
+		result, err := api.SaveAndPublishTerm(callCtx, termsClient, termid.Owner, termid.Name, string(data))
+		if result != nil {
+			if werr := c.out.Write(ctx, result); werr != nil && err == nil {
+				err = werr
+			}
+		}
+		return errors.Trace(err)
+	}
+
 	response, err := termsClient.SaveTerm(
-		context.Background(),
+		callCtx,
 		termid.Owner,
 		termid.Name,
 		string(data),
@@ -130,3 +212,103 @@ func (c *pushTermCommand) Run(ctx *cmd.Context) error {
 	}
 	return nil
 }
+
+// releaseLatest releases the given latest revision of the term, unless
+// it has already been released.
+func releaseLatest(ctx context.Context, client api.Client, latest *wireformat.Term) (*api.ReleaseResult, error) {
+	result := &api.ReleaseResult{
+		TermID: termIDString(latest),
+	}
+	if latest.Published {
+		result.ReleasedID = result.TermID
+		return result, nil
+	}
+	released, err := client.Publish(ctx, latest.Owner, latest.Name, latest.Revision)
+	if err != nil {
+		return result, errors.Annotatef(err, "failed to release term %q", result.TermID)
+	}
+	result.ReleasedID = released
+	return result, nil
+}
+
+// pushPlan describes the outcome of pushing a term.
+type pushPlan struct {
+	// Term holds the id of the revision that would be created. It is
+	// empty when the content is unchanged and the push is not forced,
+	// as no revision would be created.
+	Term string `json:"term,omitempty" yaml:"term,omitempty"`
+
+	// Previous holds the id of the current latest revision, if any.
+	Previous string `json:"previous,omitempty" yaml:"previous,omitempty"`
+
+	// Unchanged reports whether the content is the same as the
+	// content of the latest revision.
+	Unchanged bool `json:"unchanged" yaml:"unchanged"`
+
+	// LinesAdded and LinesRemoved summarize the differences with the
+	// content of the latest revision.
+	LinesAdded   int `json:"lines-added" yaml:"lines-added"`
+	LinesRemoved int `json:"lines-removed" yaml:"lines-removed"`
+
+	// Release reports whether the new revision, or the latest revision
+	// if no revision would be created, would be released.
+	Release bool `json:"release,omitempty" yaml:"release,omitempty"`
+
+	// latest holds the current latest revision of the term.
+	latest *wireformat.Term
+}
+
+// planPush returns what pushing the content to the specified term
+// would do, without pushing it. Unless force is true, no revision is
+// planned when the content is the same as the content of the latest
+// revision.
+func planPush(ctx context.Context, client api.Client, termID *charm.TermsId, content string, force bool) (*pushPlan, error) {
+	latest, err := client.GetTerm(ctx, termID.Owner, termID.Name, 0)
+	if errors.IsNotFound(errors.Cause(err)) {
+		latest = &wireformat.Term{Owner: termID.Owner, Name: termID.Name}
+	} else if err != nil {
+		return nil, errors.Annotatef(err, "failed to retrieve term %q", termID.String())
+	}
+	next := &wireformat.Term{
+		Owner:    termID.Owner,
+		Name:     termID.Name,
+		Revision: latest.Revision + 1,
+		Content:  content,
+	}
+	plan := &pushPlan{
+		latest:    latest,
+		Unchanged: latest.Revision > 0 && sameContent(latest.Content, content),
+	}
+	if !plan.Unchanged || force {
+		plan.Term = termIDString(next)
+	}
+	if latest.Revision > 0 {
+		plan.Previous = termIDString(latest)
+	}
+	// Compare the normalized contents so that the line counts agree
+	// with Unchanged.
+	from, to := *latest, *next
+	from.Content = normalizeLineEndings(from.Content)
+	to.Content = normalizeLineEndings(to.Content)
+	plan.LinesAdded, plan.LinesRemoved = diffTerms(&from, &to).lineCounts()
+	return plan, nil
+}
+
+// sameContent reports whether the two contents are the same, ignoring
+// differences in line endings.
+func sameContent(a, b string) bool {
+	return contentHash(a) == contentHash(b)
+}
+
+// contentHash returns the SHA-256 hash of the content with its line
+// endings normalized to "\n".
+func contentHash(content string) [sha256.Size]byte {
+	return sha256.Sum256([]byte(normalizeLineEndings(content)))
+}
+
+// normalizeLineEndings returns the content with its line endings
+// normalized to "\n".
+func normalizeLineEndings(content string) string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	return strings.ReplaceAll(content, "\r", "\n")
+}
@@ -0,0 +1,415 @@
+// Copyright 2020 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package cmd
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/juju/charm/v8"
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"github.com/juju/gnuflag"
+	"gopkg.in/yaml.v2"
+
+	"github.com/juju/terms-client/api"
+)
+
+const pushTermsDoc = `
+push-terms pushes all the Terms and Conditions documents found in a
+directory. Documents whose content is the same as the content of the
+latest revision of their term are skipped, unless --force is specified.
+
+The term of each document is taken from the terms.yaml manifest in the
+directory if there is one, for example:
+
+    terms:
+    - file: plans/enterprise.md
+      term: owner/enterprise-plan
+
+Otherwise the documents are mapped to terms by their path:
+<owner>/<name>.md (or .txt) is pushed to the term owner/name and
+<name>.md at the top of the directory to the term name. Documents whose
+path does not form a valid term id, such as README.md, are ignored
+and reported as such.
+Examples
+push-terms ./terms
+   pushes the changed documents in ./terms.
+push-terms --release --parallel 8 ./terms
+   pushes and releases the changed documents in ./terms, running up to
+   8 requests concurrently.
+`
+const pushTermsPurpose = "push a directory of Terms and Conditions documents"
+
+// termsManifest is the name of the manifest file mapping documents
+// to terms.
+const termsManifest = "terms.yaml"
+
+// NewPushTermsCommand returns a new command that can be used to push
+// a directory of Terms and Conditions documents.
+func NewPushTermsCommand() cmd.Command {
+	return &pushTermsCommand{
+		baseCommand: baseCommand{uncached: true},
+		table:       newPushResultTable("term", "status", "id", "error"),
+	}
+}
+
+type pushTermsCommand struct {
+	baseCommand
+	out   cmd.Output
+	table *table
+
+	Dir      string
+	Release  bool
+	Force    bool
+	Parallel int
+}
+
+// SetFlags implements Command.SetFlags.
+func (c *pushTermsCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, defaultFormat("yaml"), c.table.formatters())
+	c.table.SetFlags(f)
+	f.BoolVar(&c.Release, "release", false, "release the pushed revisions")
+	f.BoolVar(&c.Force, "force", false, "push new revisions even if the content is unchanged")
+	f.IntVar(&c.Parallel, "parallel", defaultParallel, "maximum number of documents pushed concurrently")
+	c.baseCommand.SetFlags(f)
+}
+
+// Info implements Command.Info.
+func (c *pushTermsCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "push-terms",
+		Args:    "<directory>",
+		Purpose: pushTermsPurpose,
+		Doc:     pushTermsDoc,
+	}
+}
+
+// Init reads and verifies the arguments.
+func (c *pushTermsCommand) Init(args []string) error {
+	if len(args) < 1 {
+		return errors.New("missing arguments")
+	}
+	dir, args := args[0], args[1:]
+	if err := cmd.CheckEmpty(args); err != nil {
+		return errors.Errorf("unknown arguments: %v", strings.Join(args, ","))
+	}
+	if c.Parallel < 1 {
+		return errors.Errorf("invalid --parallel value %d, must be at least 1", c.Parallel)
+	}
+	c.Dir = dir
+	return nil
+}
+
+// Description returns a one-line description of the command.
+func (c *pushTermsCommand) Description() string {
+	return pushTermsPurpose
+}
+
+// Run implements Command.Run.
+func (c *pushTermsCommand) Run(ctx *cmd.Context) error {
+	dir := ctx.AbsPath(c.Dir)
+	documents, err := termDocuments(dir, func(file string, err error) {
+		ctx.Infof("Ignoring %s: %v", file, err)
+	})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(documents) == 0 {
+		ctx.Infof("No documents found in %s.", c.Dir)
+		return nil
+	}
+
+	bakeryClient, cleanup, err := c.NewClient(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer cleanup()
+	callCtx, cancel := c.newContext(ctx)
+	defer cancel()
+	termsClient, err := c.newTermsClient(bakeryClient)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	results := make([]pushResult, len(documents))
+	sem := make(chan struct{}, c.Parallel)
+	var wg sync.WaitGroup
+	for i, doc := range documents {
+		wg.Add(1)
+		go func(i int, doc termDocument) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-callCtx.Done():
+				results[i] = failedPush(doc, errors.Trace(callCtx.Err()))
+				return
+			}
+			if err := callCtx.Err(); err != nil {
+				results[i] = failedPush(doc, errors.Trace(err))
+				return
+			}
+			results[i] = c.push(callCtx, termsClient, dir, doc)
+		}(i, doc)
+	}
+	wg.Wait()
+
+	if err := c.out.Write(ctx, results); err != nil {
+		return errors.Trace(err)
+	}
+	var failed int
+	for _, r := range results {
+		if r.Status == pushFailed {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return errors.Errorf("failed to push %d of %d document(s)", failed, len(results))
+	}
+	return nil
+}
+
+// Statuses of a pushed document.
+const (
+	pushCreated   = "created"
+	pushReleased  = "released"
+	pushUnchanged = "unchanged"
+	pushFailed    = "failed"
+)
+
+// pushResult holds the outcome of pushing a document.
+type pushResult struct {
+	// File holds the path of the document relative to the pushed
+	// directory.
+	File string `json:"file" yaml:"file"`
+
+	// Term holds the term the document is pushed to.
+	Term string `json:"term" yaml:"term"`
+
+	// Status holds the outcome of the push.
+	Status string `json:"status" yaml:"status"`
+
+	// TermID holds the id of the latest revision of the term
+	// after the push.
+	TermID string `json:"term-id,omitempty" yaml:"term-id,omitempty"`
+
+	// Error holds the reason of the failure, if any.
+	Error string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// failedPush returns the result of a document that failed to be pushed
+// with the given error.
+func failedPush(doc termDocument, err error) pushResult {
+	return pushResult{
+		File:   doc.File,
+		Term:   doc.TermID.String(),
+		Status: pushFailed,
+		Error:  err.Error(),
+	}
+}
+
+// push pushes a single document, releasing it if required.
+func (c *pushTermsCommand) push(ctx context.Context, client api.Client, dir string, doc termDocument) pushResult {
+	result := pushResult{
+		File: doc.File,
+		Term: doc.TermID.String(),
+	}
+	fail := func(err error) pushResult {
+		result.Status = pushFailed
+		result.Error = err.Error()
+		return result
+	}
+	data, err := readFile(filepath.Join(dir, doc.File))
+	if err != nil {
+		return fail(errors.Annotatef(err, "could not read contents of %q", doc.File))
+	}
+	if !c.Force {
+		plan, err := planPush(ctx, client, doc.TermID, string(data), false)
+		if err != nil {
+			return fail(err)
+		}
+		if plan.Unchanged {
+			result.Status = pushUnchanged
+			result.TermID = plan.Previous
+			if !c.Release || plan.latest.Published {
+				return result
+			}
+			if _, err := releaseLatest(ctx, client, plan.latest); err != nil {
+				return fail(err)
+			}
+			result.Status = pushReleased
+			return result
+		}
+	}
+	if c.Release {
+		released, err := api.SaveAndPublishTerm(ctx, client, doc.TermID.Owner, doc.TermID.Name, string(data))
+		if released != nil {
+			result.TermID = released.TermID
+		}
+		if err != nil {
+			return fail(err)
+		}
+		result.Status = pushReleased
+		return result
+	}
+	termID, err := client.SaveTerm(ctx, doc.TermID.Owner, doc.TermID.Name, string(data))
+	if err != nil {
+		return fail(errors.Annotate(err, "failed to save term"))
+	}
+	result.Status = pushCreated
+	result.TermID = termID
+	return result
+}
+
+// termDocument maps a document to the term it is pushed to.
+type termDocument struct {
+	File   string
+	TermID *charm.TermsId
+}
+
+// termDocuments returns the documents found in the directory, ordered
+// by term. The documents are listed in the terms.yaml manifest, if
+// present, or are found by their path otherwise, in which case ignore
+// is called for the documents whose path does not form a valid term id.
+func termDocuments(dir string, ignore func(file string, err error)) ([]termDocument, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if !info.IsDir() {
+		return nil, errors.Errorf("%q is not a directory", dir)
+	}
+	var docs []termDocument
+	manifest := filepath.Join(dir, termsManifest)
+	if data, err := ioutil.ReadFile(manifest); err == nil {
+		docs, err = readTermsManifest(data)
+		if err != nil {
+			return nil, errors.Annotatef(err, "invalid manifest %q", manifest)
+		}
+	} else if os.IsNotExist(err) {
+		docs, err = findTermDocuments(dir, ignore)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	} else {
+		return nil, errors.Trace(err)
+	}
+	seen := make(map[string]string)
+	for _, doc := range docs {
+		id := doc.TermID.String()
+		if file, ok := seen[id]; ok {
+			return nil, errors.Errorf("both %q and %q map to term %q", file, doc.File, id)
+		}
+		seen[id] = doc.File
+	}
+	sort.Slice(docs, func(i, j int) bool {
+		return docs[i].TermID.String() < docs[j].TermID.String()
+	})
+	return docs, nil
+}
+
+// readTermsManifest returns the documents listed in the manifest.
+func readTermsManifest(data []byte) ([]termDocument, error) {
+	var manifest struct {
+		Terms []struct {
+			File string `yaml:"file"`
+			Term string `yaml:"term"`
+		} `yaml:"terms"`
+	}
+	if err := yaml.UnmarshalStrict(data, &manifest); err != nil {
+		return nil, errors.Trace(err)
+	}
+	docs := make([]termDocument, len(manifest.Terms))
+	for i, t := range manifest.Terms {
+		if t.File == "" {
+			return nil, errors.Errorf("no file specified for term %q", t.Term)
+		}
+		if filepath.IsAbs(t.File) || isOutside(t.File) {
+			return nil, errors.Errorf("file %q is outside the directory", t.File)
+		}
+		termID, err := parseNewTermID(t.Term)
+		if err != nil {
+			return nil, errors.Annotatef(err, "invalid term for %q", t.File)
+		}
+		docs[i] = termDocument{File: filepath.Clean(t.File), TermID: termID}
+	}
+	return docs, nil
+}
+
+// isOutside reports whether the relative path refers to a file outside
+// the directory it is relative to.
+func isOutside(path string) bool {
+	return strings.SplitN(filepath.ToSlash(filepath.Clean(path)), "/", 2)[0] == ".."
+}
+
+// findTermDocuments returns the documents in the directory named
+// <owner>/<name>.md or <name>.md (or .txt). Other documents, whose
+// path does not form a valid term id, are ignored after calling
+// ignore with the reason.
+func findTermDocuments(dir string, ignore func(file string, err error)) ([]termDocument, error) {
+	var docs []termDocument
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		if strings.HasPrefix(info.Name(), ".") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			if len(parts) > 1 {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		ext := filepath.Ext(rel)
+		if ext != ".md" && ext != ".txt" {
+			return nil
+		}
+		id := strings.TrimSuffix(filepath.ToSlash(rel), ext)
+		termID, err := parseNewTermID(id)
+		if err != nil {
+			ignore(filepath.ToSlash(rel), err)
+			return nil
+		}
+		docs = append(docs, termDocument{File: rel, TermID: termID})
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return docs, nil
+}
+
+// parseNewTermID parses the id of a term to push, which must not
+// specify a revision or a tenant.
+func parseNewTermID(id string) (*charm.TermsId, error) {
+	termID, err := charm.ParseTerm(id)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if termID.Revision > 0 {
+		return nil, errors.Errorf("can't specify a revision with a new term")
+	}
+	if termID.Tenant != "" {
+		return nil, errors.Errorf("can't specify a tenant with a new term")
+	}
+	return termID, nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2020 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package cmd
+
+import (
+	"strings"
+
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"github.com/juju/gnuflag"
+)
+
+const renderTermDoc = `
+render-term renders a Terms and Conditions document written as a Go
+text/template template and prints the result, for review before
+pushing it with push-term. All the values used by the template must be
+specified, with --set or in a YAML values file. Values set with --set
+take precedence over the ones in the values file.
+Examples
+render-term --set company="Acme Corp" --set jurisdiction=England terms.md.tmpl
+   prints terms.md.tmpl with {{.company}} and {{.jurisdiction}}
+   replaced by the specified values.
+render-term --values acme.yaml terms.md.tmpl
+   prints terms.md.tmpl rendered with the values held in acme.yaml.
+`
+const renderTermPurpose = "render a Terms and Conditions template"
+
+// NewRenderTermCommand returns a new command that can be used to
+// render Terms and Conditions templates.
+func NewRenderTermCommand() cmd.Command {
+	return &renderTermCommand{}
+}
+
+type renderTermCommand struct {
+	cmd.CommandBase
+	template templateFlags
+
+	TermFilename string
+}
+
+// SetFlags implements Command.SetFlags.
+func (c *renderTermCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.template.SetFlags(f)
+}
+
+// Info implements Command.Info.
+func (c *renderTermCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "render-term",
+		Args:    "<filename>",
+		Purpose: renderTermPurpose,
+		Doc:     renderTermDoc,
+	}
+}
+
+// Init reads and verifies the arguments.
+func (c *renderTermCommand) Init(args []string) error {
+	if len(args) < 1 {
+		return errors.New("missing arguments")
+	}
+	if err := cmd.CheckEmpty(args[1:]); err != nil {
+		return errors.Errorf("unknown arguments: %v", strings.Join(args[1:], ","))
+	}
+	c.TermFilename = args[0]
+	return nil
+}
+
+// Description returns a one-line description of the command.
+func (c *renderTermCommand) Description() string {
+	return renderTermPurpose
+}
+
+// Run implements Command.Run.
+func (c *renderTermCommand) Run(ctx *cmd.Context) error {
+	data, err := readFile(c.TermFilename)
+	if err != nil {
+		return errors.Annotatef(err, "could not read contents of %q", c.TermFilename)
+	}
+	rendered, err := c.template.render(c.TermFilename, data)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	_, err = ctx.Stdout.Write(rendered)
+	return errors.Trace(err)
+}
@@ -4,15 +4,12 @@
 package cmd
 
 import (
-	"context"
 	"strings"
 
 	"github.com/juju/charm/v8"
 	"github.com/juju/cmd"
 	"github.com/juju/errors"
 	"github.com/juju/gnuflag"
-
-	"github.com/juju/terms-client/api"
 )
 
 const showTermDoc = `
@@ -28,12 +25,15 @@ const showTermPurpose = "shows the specified term"
 // NewShowTermCommand returns a new command that can be used
 // to shows Terms and Conditions document.
 func NewShowTermCommand() cmd.Command {
-	return &showTermCommand{}
+	return &showTermCommand{
+		table: newTermTable("id", "owner", "name", "revision", "published", "created"),
+	}
 }
 
 type showTermCommand struct {
 	baseCommand
-	out cmd.Output
+	out   cmd.Output
+	table *table
 
 	TermID      string
 	ShowContent bool
@@ -41,7 +41,8 @@ type showTermCommand struct {
 
 // SetFlags implements Command.SetFlags.
 func (c *showTermCommand) SetFlags(f *gnuflag.FlagSet) {
-	c.out.AddFlags(f, "yaml", cmd.DefaultFormatters.Formatters())
+	c.out.AddFlags(f, defaultFormat("yaml"), c.table.formatters())
+	c.table.SetFlags(f)
 	f.BoolVar(&c.ShowContent, "content", false, "show term contents only")
 	c.baseCommand.SetFlags(f)
 }
@@ -82,9 +83,9 @@ func (c *showTermCommand) Run(ctx *cmd.Context) error {
 		return errors.Trace(err)
 	}
 	defer cleanup()
-	termsClient, err := clientNew(
-		api.HTTPClient(bakeryClient),
-	)
+	callCtx, cancel := c.newContext(ctx)
+	defer cancel()
+	termsClient, err := c.newTermsClient(bakeryClient)
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -94,7 +95,7 @@ func (c *showTermCommand) Run(ctx *cmd.Context) error {
 		return errors.Annotate(err, "invalid term format")
 	}
 
-	response, err := termsClient.GetTerm(context.Background(), termsId.Owner, termsId.Name, termsId.Revision)
+	response, err := termsClient.GetTerm(callCtx, termsId.Owner, termsId.Name, termsId.Revision)
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -0,0 +1,302 @@
+// Copyright 2020 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package cmd
+
+import (
+	"time"
+
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"github.com/juju/gnuflag"
+)
+
+const termsDoc = `
+charm-terms manages Terms and Conditions documents and the agreements
+made to them.
+
+The following global flags may be given before the command name, in
+which case they are passed on to the command if it accepts them, as if
+given after its name. Flags given after the command name take
+precedence.
+
+--url <url>
+    host and port of the terms service
+--profile <name>
+    name of the configuration profile to use, see "help profiles"
+-B, --no-browser-login
+    do not use web browser for authentication
+--auth browser|no-browser|agent
+    authentication mode
+--agent-file <file>
+    file holding the agent credentials used with agent authentication
+--macaroons <file>
+    file holding pre-discharged macaroons sent to the terms service
+--no-cache
+    do not use the local cache of terms
+--timeout <duration>
+    maximum time to wait for the remote services (e.g. 30s)
+--format <format>
+    output format of the command
+Examples
+charm-terms push-term terms.txt owner/enterprise-plan
+   pushes a new revision of the enterprise-plan term owned by owner.
+charm-terms --url https://terms.example.com --format json terms
+   lists the terms owned by the current user and their groups using
+   the terms service at terms.example.com.
+`
+const termsPurpose = "manage Terms and Conditions documents and agreements"
+
+const termIDsHelp = `
+Terms and Conditions documents are identified by
+
+    [<owner>/]<name>[/<revision>]
+
+where the owner is the user or group owning the term, and may be
+omitted for terms without an owner, and the revision is a positive
+number. Commands listing or pushing terms do not accept a revision,
+commands showing or releasing terms use the latest revision if none
+is specified.
+
+For example: owner/enterprise-plan/3 or enterprise-plan.
+`
+
+const environmentHelp = `
+The following environment variables are used by the terms commands:
+
+JUJU_TERMS
+    The URL of the terms service, used unless --url is specified.
+JUJU_IDENTITY
+    The URL of the identity manager, used unless --identity-url is
+    specified.
+JUJU_TERMS_PROFILE
+    The configuration profile used unless --profile is specified.
+JUJU_TERMS_AUTH
+    The authentication mode used unless --auth or -B is specified.
+JUJU_TERMS_AGENT
+    The agent credentials used with agent authentication unless
+    --agent-file is specified, in the JSON format of agent files.
+    Selects agent authentication unless a mode is specified by a flag
+    or $JUJU_TERMS_AUTH.
+BAKERY_AGENT_FILE
+    The agent file used with agent authentication unless --agent-file
+    or $JUJU_TERMS_AGENT is specified. Selects agent authentication
+    like $JUJU_TERMS_AGENT.
+JUJU_TERMS_MACAROONS
+    Pre-discharged macaroons sent to the terms service unless
+    --macaroons is specified.
+JUJU_DATA
+    The directory holding the juju client data, including the local
+    cache of terms.
+JUJU_COOKIEFILE
+    The file holding the authorization cookies, ~/.go-cookies by
+    default.
+`
+
+const profilesHelp = `
+Profiles group the settings used to reach the terms service and the
+identity manager. They are defined in the terms-client.yaml file in
+the juju data directory ($JUJU_DATA or ~/.local/share/juju), for
+example:
+
+    default-profile: production
+    profiles:
+      production:
+        terms-url: https://api.jujucharms.com/terms
+      staging:
+        terms-url: https://terms.staging.example.com
+        identity-url: https://identity.staging.example.com
+        cookie-file: ~/.staging-cookies
+        auth: no-browser
+
+The profile is selected with --profile, or $JUJU_TERMS_PROFILE, and
+defaults to default-profile. Each setting of the profile may be
+overridden by a command line flag or an environment variable: flags
+take precedence over environment variables, which take precedence
+over the profile.
+
+auth is either "browser", to log in using a web browser, "no-browser"
+(same as -B) to log in on the terminal, or "agent" to log in
+non-interactively with the agent credentials held in agent-file, as
+needed by unattended pipelines. When auth is not set, agent-file
+selects agent authentication. Agent files are JSON documents holding
+the agent's key pair and its username on each identity manager:
+
+    {
+      "key": {"public": "...", "private": "..."},
+      "agents": [{"url": "https://api.jujucharms.com/identity", "username": "ci-bot"}]
+    }
+
+macaroons-file holds pre-discharged macaroons sent to the terms
+service, as a JSON array made of the primary macaroon and its
+discharges, optionally base64 encoded. They are never stored in the
+cookie file.
+`
+
+// NewSuperCommand returns a new command that runs all the terms
+// commands as subcommands.
+func NewSuperCommand() cmd.Command {
+	c := &superCommand{
+		SuperCommand: cmd.NewSuperCommand(cmd.SuperCommandParams{
+			Name:    "charm-terms",
+			Purpose: termsPurpose,
+			Doc:     termsDoc,
+		}),
+		commands: make(map[string]func() cmd.Command),
+	}
+	c.register(NewAgreeCommand)
+	c.register(NewCheckTermsCommand)
+	c.register(NewDiffTermCommand)
+	c.register(NewExportAgreementsCommand)
+	c.register(NewLintTermCommand)
+	c.register(NewListAgreementsCommand)
+	c.register(NewListTermsCommand)
+	c.register(NewPushTermCommand)
+	c.register(NewPushTermsCommand)
+	c.register(NewReleaseTermCommand)
+	c.register(NewRenderTermCommand)
+	c.register(NewShowTermCommand)
+	c.register(NewTermHistoryCommand)
+	c.register(NewTermsStatusCommand)
+
+	// The names of the legacy charm-* binaries.
+	c.registerAlias("list-terms", "terms")
+
+	c.AddHelpTopic("term-ids", "how terms are identified", termIDsHelp)
+	c.AddHelpTopic("environment", "environment variables used by the terms commands", environmentHelp)
+	c.AddHelpTopic("profiles", "configuration profiles of the terms commands", profilesHelp)
+	return c
+}
+
+// superCommand is a cmd.SuperCommand that also accepts the flags
+// shared by the terms commands before the command name.
+type superCommand struct {
+	*cmd.SuperCommand
+	global globalFlags
+
+	// commands holds the constructors of the registered terms
+	// commands, keyed by name, including aliases.
+	commands map[string]func() cmd.Command
+}
+
+// register registers the terms command returned by newCommand as a
+// subcommand.
+func (c *superCommand) register(newCommand func() cmd.Command) {
+	subcmd := newCommand()
+	c.Register(subcmd)
+	info := subcmd.Info()
+	c.commands[info.Name] = newCommand
+	for _, alias := range info.Aliases {
+		c.commands[alias] = newCommand
+	}
+}
+
+// registerAlias makes the registered terms command available under
+// another name.
+func (c *superCommand) registerAlias(name, forName string) {
+	c.RegisterAlias(name, forName, nil)
+	c.commands[name] = c.commands[forName]
+}
+
+// definesFlag returns a function reporting whether the named terms
+// command defines a flag.
+func (c *superCommand) definesFlag(name string) func(flag string) bool {
+	f := gnuflag.NewFlagSet(name, gnuflag.ContinueOnError)
+	c.commands[name]().SetFlags(f)
+	return func(flag string) bool {
+		return f.Lookup(flag) != nil
+	}
+}
+
+// SetFlags implements Command.SetFlags.
+func (c *superCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.SuperCommand.SetFlags(f)
+	// Flags added after the SuperCommand's ones are not passed
+	// on to subcommands: they are passed as arguments by Init.
+	c.global.AddFlags(f)
+}
+
+// Init implements Command.Init. The global flags are passed on to
+// the command, except those it does not define.
+func (c *superCommand) Init(args []string) error {
+	if c.global.isSet() {
+		if len(args) == 0 || c.commands[args[0]] == nil {
+			return errors.New("global flags must be followed by a terms command")
+		}
+		flags := c.global.args(c.definesFlag(args[0]))
+		args = append(append([]string{args[0]}, flags...), args[1:]...)
+	}
+	return c.SuperCommand.Init(args)
+}
+
+// globalFlags holds the flags shared by the terms commands that may be
+// given before the command name.
+type globalFlags struct {
+	ServiceURL    string
+	ProfileName   string
+	NoBrowser     bool
+	Auth          string
+	AgentFile     string
+	MacaroonsFile string
+	NoCache       bool
+	Timeout       time.Duration
+	Format        string
+}
+
+// AddFlags implements cmd.FlagAdder.
+func (g *globalFlags) AddFlags(f *gnuflag.FlagSet) {
+	f.StringVar(&g.ServiceURL, "url", "", "host and port of the terms service")
+	f.StringVar(&g.ProfileName, "profile", "", "name of the configuration profile to use")
+	f.BoolVar(&g.NoBrowser, "B", false, "Do not use web browser for authentication")
+	f.BoolVar(&g.NoBrowser, "no-browser-login", false, "")
+	f.StringVar(&g.Auth, "auth", "", "authentication mode: browser, no-browser or agent")
+	f.StringVar(&g.AgentFile, "agent-file", "", "file holding the agent credentials used with agent authentication")
+	f.StringVar(&g.MacaroonsFile, "macaroons", "", "file holding pre-discharged macaroons sent to the terms service")
+	f.BoolVar(&g.NoCache, "no-cache", false, "do not use the local cache of terms")
+	f.DurationVar(&g.Timeout, "timeout", 0, "maximum time to wait for the remote services (e.g. 30s); 0 means no timeout")
+	f.StringVar(&g.Format, "format", "", "output format of the command")
+}
+
+// isSet reports whether any global flag is set.
+func (g *globalFlags) isSet() bool {
+	return len(g.args(func(string) bool { return true })) > 0
+}
+
+// args returns the flags to pass on to a command, given a function
+// reporting whether the command defines a flag.
+func (g *globalFlags) args(defined func(flag string) bool) []string {
+	var args []string
+	add := func(flag, arg string) {
+		if defined(flag) {
+			args = append(args, arg)
+		}
+	}
+	if g.ServiceURL != "" {
+		add("url", "--url="+g.ServiceURL)
+	}
+	if g.ProfileName != "" {
+		add("profile", "--profile="+g.ProfileName)
+	}
+	if g.NoBrowser {
+		add("B", "-B")
+	}
+	if g.Auth != "" {
+		add("auth", "--auth="+g.Auth)
+	}
+	if g.AgentFile != "" {
+		add("agent-file", "--agent-file="+g.AgentFile)
+	}
+	if g.MacaroonsFile != "" {
+		add("macaroons", "--macaroons="+g.MacaroonsFile)
+	}
+	if g.NoCache {
+		add("no-cache", "--no-cache")
+	}
+	if g.Timeout != 0 {
+		add("timeout", "--timeout="+g.Timeout.String())
+	}
+	if g.Format != "" {
+		add("format", "--format="+g.Format)
+	}
+	return args
+}
@@ -0,0 +1,344 @@
+// Copyright 2020 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"github.com/juju/gnuflag"
+
+	"github.com/juju/terms-client/api/wireformat"
+)
+
+var (
+	// isTerminal reports whether the file is a terminal.
+	isTerminal = func(f *os.File) bool {
+		info, err := f.Stat()
+		return err == nil && info.Mode()&os.ModeCharDevice != 0
+	}
+)
+
+// defaultFormat returns the name of the default output format:
+// tabular if stdout is a terminal and fallback otherwise. The default
+// format is needed when the flags are registered, before the command's
+// context is known, so it follows the process stdout rather than the
+// command's ctx.Stdout.
+func defaultFormat(fallback string) string {
+	if isTerminal(os.Stdout) {
+		return "tabular"
+	}
+	return fallback
+}
+
+// column defines a column of tabular output.
+type column struct {
+	// value returns the value of the cell in the specified row.
+	value func(row interface{}) string
+
+	// numeric specifies that the values are sorted as numbers.
+	numeric bool
+}
+
+// termColumns holds the columns available when showing terms.
+var termColumns = map[string]column{
+	"id": {value: func(row interface{}) string {
+		return row.(wireformat.Term).Id
+	}},
+	"owner": {value: func(row interface{}) string {
+		return row.(wireformat.Term).Owner
+	}},
+	"name": {value: func(row interface{}) string {
+		return row.(wireformat.Term).Name
+	}},
+	"revision": {value: func(row interface{}) string {
+		return strconv.Itoa(row.(wireformat.Term).Revision)
+	}, numeric: true},
+	"title": {value: func(row interface{}) string {
+		return row.(wireformat.Term).Title
+	}},
+	"published": {value: func(row interface{}) string {
+		return strconv.FormatBool(row.(wireformat.Term).Published)
+	}},
+	"created": {value: func(row interface{}) string {
+		return formatTime(time.Time(row.(wireformat.Term).CreatedOn))
+	}},
+}
+
+// agreementColumns holds the columns available when showing
+// agreements.
+var agreementColumns = map[string]column{
+	"term": {value: func(row interface{}) string {
+		a := row.(wireformat.AgreementResponse)
+		return fmt.Sprintf("%s/%d", agreementTermName(a), a.Revision)
+	}},
+	"user": {value: func(row interface{}) string {
+		return row.(wireformat.AgreementResponse).User
+	}},
+	"owner": {value: func(row interface{}) string {
+		return row.(wireformat.AgreementResponse).Owner
+	}},
+	"name": {value: func(row interface{}) string {
+		return row.(wireformat.AgreementResponse).Term
+	}},
+	"revision": {value: func(row interface{}) string {
+		return strconv.Itoa(row.(wireformat.AgreementResponse).Revision)
+	}, numeric: true},
+	"created": {value: func(row interface{}) string {
+		return formatTime(row.(wireformat.AgreementResponse).CreatedOn)
+	}},
+}
+
+// unsignedTermColumns holds the columns available when showing the
+// terms that have not been agreed to.
+var unsignedTermColumns = map[string]column{
+	"term": {value: func(row interface{}) string {
+		t := row.(wireformat.GetTermsResponse)
+		if t.Owner == "" {
+			return fmt.Sprintf("%s/%d", t.Name, t.Revision)
+		}
+		return fmt.Sprintf("%s/%s/%d", t.Owner, t.Name, t.Revision)
+	}},
+	"owner": {value: func(row interface{}) string {
+		return row.(wireformat.GetTermsResponse).Owner
+	}},
+	"name": {value: func(row interface{}) string {
+		return row.(wireformat.GetTermsResponse).Name
+	}},
+	"revision": {value: func(row interface{}) string {
+		return strconv.Itoa(row.(wireformat.GetTermsResponse).Revision)
+	}, numeric: true},
+	"title": {value: func(row interface{}) string {
+		return row.(wireformat.GetTermsResponse).Title
+	}},
+	"created": {value: func(row interface{}) string {
+		return formatTime(row.(wireformat.GetTermsResponse).CreatedOn)
+	}},
+}
+
+// pushResultColumns holds the columns available when showing the
+// results of push-terms.
+var pushResultColumns = map[string]column{
+	"term": {value: func(row interface{}) string {
+		return row.(pushResult).Term
+	}},
+	"file": {value: func(row interface{}) string {
+		return row.(pushResult).File
+	}},
+	"status": {value: func(row interface{}) string {
+		return row.(pushResult).Status
+	}},
+	"id": {value: func(row interface{}) string {
+		return row.(pushResult).TermID
+	}},
+	"error": {value: func(row interface{}) string {
+		return row.(pushResult).Error
+	}},
+}
+
+// statusCheckColumns holds the columns available when showing the
+// status checks of the terms service.
+var statusCheckColumns = map[string]column{
+	"check": {value: func(row interface{}) string {
+		return row.(statusCheck).Check
+	}},
+	"name": {value: func(row interface{}) string {
+		return row.(statusCheck).Name
+	}},
+	"status": {value: func(row interface{}) string {
+		return row.(statusCheck).Status
+	}},
+	"value": {value: func(row interface{}) string {
+		return row.(statusCheck).Value
+	}},
+	"duration": {value: func(row interface{}) string {
+		return row.(statusCheck).Duration
+	}},
+}
+
+// table renders terms, agreements, unsigned terms, push results or
+// status checks in tabular format. The columns shown and the column
+// used to sort rows may be selected using command line flags.
+type table struct {
+	columns        map[string]column
+	defaultColumns []string
+
+	// Columns holds a comma separated list of the columns to show.
+	Columns string
+
+	// SortBy holds the column rows are sorted by. The order is
+	// descending if the column name is prefixed with "-".
+	SortBy string
+}
+
+// newTermTable returns a table showing terms.
+func newTermTable(defaultColumns ...string) *table {
+	return &table{
+		columns:        termColumns,
+		defaultColumns: defaultColumns,
+	}
+}
+
+// newAgreementTable returns a table showing agreements.
+func newAgreementTable(defaultColumns ...string) *table {
+	return &table{
+		columns:        agreementColumns,
+		defaultColumns: defaultColumns,
+	}
+}
+
+// newUnsignedTermTable returns a table showing the terms that have not
+// been agreed to.
+func newUnsignedTermTable(defaultColumns ...string) *table {
+	return &table{
+		columns:        unsignedTermColumns,
+		defaultColumns: defaultColumns,
+	}
+}
+
+// newPushResultTable returns a table showing the results of push-terms.
+func newPushResultTable(defaultColumns ...string) *table {
+	return &table{
+		columns:        pushResultColumns,
+		defaultColumns: defaultColumns,
+	}
+}
+
+// newStatusCheckTable returns a table showing status checks.
+func newStatusCheckTable(defaultColumns ...string) *table {
+	return &table{
+		columns:        statusCheckColumns,
+		defaultColumns: defaultColumns,
+	}
+}
+
+// SetFlags adds the flags selecting the columns and the sort order of
+// the table.
+func (t *table) SetFlags(f *gnuflag.FlagSet) {
+	names := make([]string, 0, len(t.columns))
+	for name := range t.columns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	f.StringVar(&t.Columns, "columns", strings.Join(t.defaultColumns, ","),
+		fmt.Sprintf("comma separated list of columns shown in tabular format (%s)", strings.Join(names, ", ")))
+	f.StringVar(&t.SortBy, "sort", "", "column rows are sorted by in tabular format, prefix with - for descending order")
+}
+
+// formatters returns the output formatters including the tabular one.
+func (t *table) formatters() map[string]cmd.Formatter {
+	formatters := cmd.DefaultFormatters.Formatters()
+	formatters["tabular"] = t.format
+	return formatters
+}
+
+// format implements cmd.Formatter.
+func (t *table) format(writer io.Writer, value interface{}) error {
+	var rows []interface{}
+	switch v := value.(type) {
+	case []wireformat.Term:
+		for _, term := range v {
+			rows = append(rows, term)
+		}
+	case *wireformat.Term:
+		rows = append(rows, *v)
+	case []wireformat.AgreementResponse:
+		for _, agreement := range v {
+			rows = append(rows, agreement)
+		}
+	case []wireformat.GetTermsResponse:
+		for _, term := range v {
+			rows = append(rows, term)
+		}
+	case []pushResult:
+		for _, result := range v {
+			rows = append(rows, result)
+		}
+	case []statusCheck:
+		for _, check := range v {
+			rows = append(rows, check)
+		}
+	default:
+		return errors.Errorf("cannot format value of type %T as a table", value)
+	}
+
+	names := strings.Split(t.Columns, ",")
+	columns := make([]column, len(names))
+	for i, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		col, ok := t.columns[name]
+		if !ok {
+			return errors.Errorf("unknown column %q", name)
+		}
+		names[i], columns[i] = name, col
+	}
+	if t.SortBy != "" {
+		if err := t.sort(rows); err != nil {
+			return errors.Trace(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 1, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.ToUpper(strings.Join(names, "\t")))
+	for _, row := range rows {
+		values := make([]string, len(columns))
+		for i, col := range columns {
+			values[i] = col.value(row)
+		}
+		fmt.Fprintln(tw, strings.Join(values, "\t"))
+	}
+	if err := tw.Flush(); err != nil {
+		return errors.Trace(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	_, err := io.WriteString(writer, strings.Join(lines, "\n"))
+	return errors.Trace(err)
+}
+
+// sort sorts the rows by the column specified in SortBy.
+func (t *table) sort(rows []interface{}) error {
+	name := strings.ToLower(strings.TrimSpace(t.SortBy))
+	descending := strings.HasPrefix(name, "-")
+	name = strings.TrimPrefix(name, "-")
+	col, ok := t.columns[name]
+	if !ok {
+		return errors.Errorf("unknown sort column %q", name)
+	}
+	less := func(a, b string) bool {
+		return a < b
+	}
+	if col.numeric {
+		less = func(a, b string) bool {
+			i, _ := strconv.Atoi(a)
+			j, _ := strconv.Atoi(b)
+			return i < j
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := col.value(rows[i]), col.value(rows[j])
+		if descending {
+			return less(b, a)
+		}
+		return less(a, b)
+	})
+	return nil
+}
+
+// formatTime formats the time for tabular output.
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
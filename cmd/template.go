@@ -0,0 +1,100 @@
+// Copyright 2020 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package cmd
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/juju/errors"
+	"github.com/juju/gnuflag"
+	"gopkg.in/yaml.v2"
+)
+
+// templateFlags holds the flags specifying the values used to render
+// Terms and Conditions documents written as Go text/template templates.
+type templateFlags struct {
+	// Values holds the values set on the command line, which take
+	// precedence over the ones in the values file.
+	Values keyValues
+
+	// ValuesFile holds the path of the YAML file holding the values.
+	ValuesFile string
+}
+
+// SetFlags adds the flags specifying the template values.
+func (t *templateFlags) SetFlags(f *gnuflag.FlagSet) {
+	f.Var(&t.Values, "set", "set a template value as key=value, may be repeated")
+	f.StringVar(&t.ValuesFile, "values", "", "YAML file holding the template values")
+}
+
+// enabled reports whether any template values are specified, in which
+// case documents are rendered before being used.
+func (t *templateFlags) enabled() bool {
+	return len(t.Values) > 0 || t.ValuesFile != ""
+}
+
+// values returns the template values.
+func (t *templateFlags) values() (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+	if t.ValuesFile != "" {
+		data, err := readFile(t.ValuesFile)
+		if err != nil {
+			return nil, errors.Annotatef(err, "could not read values from %q", t.ValuesFile)
+		}
+		if err := yaml.Unmarshal(data, &values); err != nil {
+			return nil, errors.Annotatef(err, "invalid values file %q", t.ValuesFile)
+		}
+	}
+	for key, value := range t.Values {
+		values[key] = value
+	}
+	return values, nil
+}
+
+// render renders the named template with the template values. Values
+// used by the template must all be specified.
+func (t *templateFlags) render(name string, content []byte) ([]byte, error) {
+	values, err := t.values()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return nil, errors.Annotatef(err, "cannot parse template %q", name)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, values); err != nil {
+		return nil, errors.Annotatef(err, "cannot render template %q", name)
+	}
+	return buf.Bytes(), nil
+}
+
+// keyValues implements gnuflag.Value for repeated key=value flags.
+type keyValues map[string]string
+
+// Set implements gnuflag.Value.Set.
+func (kv *keyValues) Set(s string) error {
+	parts := strings.SplitN(s, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return errors.Errorf("expected key=value, got %q", s)
+	}
+	if *kv == nil {
+		*kv = make(keyValues)
+	}
+	(*kv)[parts[0]] = parts[1]
+	return nil
+}
+
+// String implements gnuflag.Value.String.
+func (kv *keyValues) String() string {
+	pairs := make([]string, 0, len(*kv))
+	for key, value := range *kv {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
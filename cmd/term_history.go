@@ -0,0 +1,108 @@
+// Copyright 2020 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package cmd
+
+import (
+	"strings"
+
+	"github.com/juju/charm/v8"
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"github.com/juju/gnuflag"
+)
+
+const termHistoryDoc = `
+term-history is used to list all revisions of a Terms and Conditions
+document, along with their release status and creation date.
+Examples
+term-history owner/enterprise-plan
+   lists all revisions of the enterprise-plan Terms and Conditions
+   owned by owner.
+`
+const termHistoryPurpose = "lists the revisions of the specified term"
+
+// NewTermHistoryCommand returns a new command that can be used
+// to list revisions of a Terms and Conditions document.
+func NewTermHistoryCommand() cmd.Command {
+	return &termHistoryCommand{
+		table: newTermTable("revision", "published", "title", "created"),
+	}
+}
+
+type termHistoryCommand struct {
+	baseCommand
+	out   cmd.Output
+	table *table
+
+	TermID *charm.TermsId
+}
+
+// SetFlags implements Command.SetFlags.
+func (c *termHistoryCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, defaultFormat("yaml"), c.table.formatters())
+	c.table.SetFlags(f)
+	c.baseCommand.SetFlags(f)
+}
+
+// Info implements Command.Info.
+func (c *termHistoryCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "term-history",
+		Args:    "<term id>",
+		Purpose: termHistoryPurpose,
+		Doc:     termHistoryDoc,
+	}
+}
+
+// Init reads and verifies the arguments.
+func (c *termHistoryCommand) Init(args []string) error {
+	if len(args) < 1 {
+		return errors.New("missing arguments")
+	}
+	if err := cmd.CheckEmpty(args[1:]); err != nil {
+		return errors.Errorf("unknown arguments: %v", strings.Join(args[1:], ","))
+	}
+	termID, err := charm.ParseTerm(args[0])
+	if err != nil {
+		return errors.Annotate(err, "invalid term format")
+	}
+	if termID.Revision != 0 {
+		return errors.New("can't specify a revision when listing revisions")
+	}
+	c.TermID = termID
+	return nil
+}
+
+// Description returns a one-line description of the command.
+func (c *termHistoryCommand) Description() string {
+	return termHistoryPurpose
+}
+
+// Run implements Command.Run.
+func (c *termHistoryCommand) Run(ctx *cmd.Context) error {
+	bakeryClient, cleanup, err := c.NewClient(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer cleanup()
+	callCtx, cancel := c.newContext(ctx)
+	defer cancel()
+	termsClient, err := c.newTermsClient(bakeryClient)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	terms, err := termsClient.ListTermRevisions(callCtx, c.TermID.Owner, c.TermID.Name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for i := range terms {
+		terms[i].Content = ""
+	}
+	err = c.out.Write(ctx, terms)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return nil
+}
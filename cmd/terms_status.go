@@ -0,0 +1,139 @@
+// Copyright 2020 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package cmd
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"github.com/juju/gnuflag"
+)
+
+const termsStatusDoc = `
+terms-status is used to show the results of the checks that form the
+status of the terms service. The command fails if any check failed.
+Examples
+terms-status
+   shows the status of the terms service, as a table when the
+   output is a terminal and in YAML format otherwise.
+terms-status --format json
+   shows the status of the terms service in JSON format.
+`
+const termsStatusPurpose = "shows the status of the terms service"
+
+// NewTermsStatusCommand returns a new command that can be used to
+// show the status of the terms service.
+func NewTermsStatusCommand() cmd.Command {
+	return &termsStatusCommand{
+		table: newStatusCheckTable("check", "status", "value", "duration"),
+	}
+}
+
+type termsStatusCommand struct {
+	baseCommand
+	out   cmd.Output
+	table *table
+}
+
+// SetFlags implements Command.SetFlags.
+func (c *termsStatusCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, defaultFormat("yaml"), c.table.formatters())
+	c.table.SetFlags(f)
+	c.baseCommand.SetFlags(f)
+}
+
+// Info implements Command.Info.
+func (c *termsStatusCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "terms-status",
+		Purpose: termsStatusPurpose,
+		Doc:     termsStatusDoc,
+	}
+}
+
+// Init reads and verifies the arguments.
+func (c *termsStatusCommand) Init(args []string) error {
+	if err := cmd.CheckEmpty(args); err != nil {
+		return errors.Errorf("unknown arguments: %v", strings.Join(args, ","))
+	}
+	return nil
+}
+
+// Description returns a one-line description of the command.
+func (c *termsStatusCommand) Description() string {
+	return termsStatusPurpose
+}
+
+// Run implements Command.Run.
+func (c *termsStatusCommand) Run(ctx *cmd.Context) error {
+	bakeryClient, cleanup, err := c.NewClient(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer cleanup()
+	callCtx, cancel := c.newContext(ctx)
+	defer cancel()
+	termsClient, err := c.newTermsClient(bakeryClient)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	status, err := termsClient.DebugStatus(callCtx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	checks := make([]statusCheck, 0, len(status.Checks))
+	var failed int
+	for key, result := range status.Checks {
+		check := statusCheck{
+			Check:    key,
+			Name:     result.Name,
+			Status:   checkPassed,
+			Value:    result.Value,
+			Duration: result.Duration.String(),
+		}
+		if !result.Passed {
+			check.Status = checkFailed
+			failed++
+		}
+		checks = append(checks, check)
+	}
+	sort.Slice(checks, func(i, j int) bool {
+		return checks[i].Check < checks[j].Check
+	})
+	if err := c.out.Write(ctx, checks); err != nil {
+		return errors.Trace(err)
+	}
+	if failed > 0 {
+		return errors.Errorf("%d of %d check(s) failed", failed, len(checks))
+	}
+	return nil
+}
+
+// Statuses of a service status check.
+const (
+	checkPassed = "pass"
+	checkFailed = "fail"
+)
+
+// statusCheck holds the result of a service status check as shown
+// by terms-status.
+type statusCheck struct {
+	// Check holds the key identifying the check.
+	Check string `json:"check" yaml:"check"`
+
+	// Name holds the human readable name of the check.
+	Name string `json:"name" yaml:"name"`
+
+	// Status holds whether the check passed or failed.
+	Status string `json:"status" yaml:"status"`
+
+	// Value holds the result of the check.
+	Value string `json:"value" yaml:"value"`
+
+	// Duration holds how long the check took to run.
+	Duration string `json:"duration" yaml:"duration"`
+}
@@ -0,0 +1,204 @@
+// Copyright 2020 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+// Package config reads the configuration file of the terms commands,
+// which holds named profiles of the services and the authentication
+// used, for example:
+//
+//	default-profile: production
+//	profiles:
+//	  production:
+//	    terms-url: https://api.jujucharms.com/terms
+//	    identity-url: https://api.jujucharms.com/identity/v1
+//	  local:
+//	    terms-url: http://localhost:8080
+//	    identity-url: http://localhost:8081
+//	    cookie-file: ~/.local-cookies
+//	    auth: no-browser
+//	  ci:
+//	    auth: agent
+//	    agent-file: ~/.config/terms-agent.json
+package config
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/juju/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// ProfileEnvVar is the name of the environment variable selecting the
+// profile used when none is specified on the command line.
+const ProfileEnvVar = "JUJU_TERMS_PROFILE"
+
+// AuthMode specifies how users authenticate to the services.
+type AuthMode string
+
+const (
+	// AuthBrowser authenticates using a web browser.
+	AuthBrowser AuthMode = "browser"
+
+	// AuthNoBrowser authenticates by prompting for the user's
+	// credentials on the terminal.
+	AuthNoBrowser AuthMode = "no-browser"
+
+	// AuthAgent authenticates non-interactively with agent
+	// credentials.
+	AuthAgent AuthMode = "agent"
+)
+
+// authModes holds the valid authentication modes.
+var authModes = []AuthMode{AuthBrowser, AuthNoBrowser, AuthAgent}
+
+// ParseAuthMode returns the authentication mode with the specified
+// name.
+func ParseAuthMode(name string) (AuthMode, error) {
+	for _, mode := range authModes {
+		if name == string(mode) {
+			return mode, nil
+		}
+	}
+	names := make([]string, len(authModes))
+	for i, mode := range authModes {
+		names[i] = string(mode)
+	}
+	return "", errors.NotValidf("auth %q (must be one of %s)", name, strings.Join(names, ", "))
+}
+
+// Profile holds the settings of a named profile. Empty fields are
+// not set by the profile.
+type Profile struct {
+	// TermsURL holds the URL of the terms service.
+	TermsURL string `yaml:"terms-url,omitempty"`
+
+	// IdentityURL holds the URL of the identity manager.
+	IdentityURL string `yaml:"identity-url,omitempty"`
+
+	// CookieFile holds the path of the file storing the
+	// authorization cookies. A leading ~ is expanded to the user's
+	// home directory.
+	CookieFile string `yaml:"cookie-file,omitempty"`
+
+	// Auth holds how users authenticate to the services.
+	Auth AuthMode `yaml:"auth,omitempty"`
+
+	// AgentFile holds the path of the file holding the agent
+	// credentials used with agent authentication, in the JSON format
+	// used by macaroon-bakery. A leading ~ is expanded to the user's
+	// home directory.
+	AgentFile string `yaml:"agent-file,omitempty"`
+
+	// MacaroonsFile holds the path of the file holding pre-discharged
+	// macaroons sent to the terms service, as a JSON array made of the
+	// primary macaroon and its discharges, optionally base64 encoded.
+	// A leading ~ is expanded to the user's home directory.
+	MacaroonsFile string `yaml:"macaroons-file,omitempty"`
+}
+
+// Config holds the contents of the configuration file.
+type Config struct {
+	// DefaultProfile holds the name of the profile used when none is
+	// selected.
+	DefaultProfile string `yaml:"default-profile,omitempty"`
+
+	// Profiles holds the profiles by name.
+	Profiles map[string]Profile `yaml:"profiles,omitempty"`
+}
+
+// Read reads the configuration file at the specified path. An empty
+// configuration is returned if the file does not exist.
+func Read(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Config{}, nil
+	} else if err != nil {
+		return nil, errors.Trace(err)
+	}
+	var cfg Config
+	if err := yaml.UnmarshalStrict(data, &cfg); err != nil {
+		return nil, errors.Annotatef(err, "invalid configuration file %q", path)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, errors.Annotatef(err, "invalid configuration file %q", path)
+	}
+	return &cfg, nil
+}
+
+// Validate checks the configuration is valid.
+func (c *Config) Validate() error {
+	if c.DefaultProfile != "" {
+		if _, ok := c.Profiles[c.DefaultProfile]; !ok {
+			return errors.Errorf("default profile %q not defined", c.DefaultProfile)
+		}
+	}
+	for _, name := range c.profileNames() {
+		if err := c.Profiles[name].validate(); err != nil {
+			return errors.Annotatef(err, "profile %q", name)
+		}
+	}
+	return nil
+}
+
+// Profile returns the profile with the specified name or, if name is
+// empty, the default profile. An empty profile is returned if name is
+// empty and no default profile is defined.
+func (c *Config) Profile(name string) (Profile, error) {
+	if name == "" {
+		name = c.DefaultProfile
+		if name == "" {
+			return Profile{}, nil
+		}
+	}
+	p, ok := c.Profiles[name]
+	if !ok {
+		if len(c.Profiles) == 0 {
+			return Profile{}, errors.NotFoundf("profile %q (no profiles defined)", name)
+		}
+		return Profile{}, errors.NotFoundf("profile %q (defined profiles: %s)", name, strings.Join(c.profileNames(), ", "))
+	}
+	return p, nil
+}
+
+// profileNames returns the sorted names of the profiles.
+func (c *Config) profileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validate checks the profile settings are valid.
+func (p Profile) validate() error {
+	for _, u := range []struct {
+		name, value string
+	}{{
+		name:  "terms-url",
+		value: p.TermsURL,
+	}, {
+		name:  "identity-url",
+		value: p.IdentityURL,
+	}} {
+		if u.value == "" {
+			continue
+		}
+		parsed, err := url.Parse(u.value)
+		if err != nil {
+			return errors.Annotatef(err, "invalid %s", u.name)
+		}
+		if parsed.Scheme == "" || parsed.Host == "" {
+			return errors.Errorf("invalid %s %q: not an absolute URL", u.name, u.value)
+		}
+	}
+	if p.Auth != "" {
+		if _, err := ParseAuthMode(string(p.Auth)); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
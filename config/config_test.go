@@ -0,0 +1,136 @@
+// Copyright 2020 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package config_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	stdtesting "testing"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/terms-client/config"
+)
+
+func Test(t *stdtesting.T) {
+	gc.TestingT(t)
+}
+
+type configSuite struct{}
+
+var _ = gc.Suite(&configSuite{})
+
+func (s *configSuite) TestRead(c *gc.C) {
+	tests := []struct {
+		about   string
+		content string
+		config  *config.Config
+		err     string
+	}{{
+		about: "profiles",
+		content: `
+default-profile: production
+profiles:
+  production:
+    terms-url: https://terms.example.com
+    identity-url: https://identity.example.com
+  local:
+    terms-url: http://localhost:8080
+    cookie-file: ~/.local-cookies
+    auth: no-browser
+  ci:
+    auth: agent
+    agent-file: /etc/terms/agent.json
+    macaroons-file: /etc/terms/macaroons.json
+`,
+		config: &config.Config{
+			DefaultProfile: "production",
+			Profiles: map[string]config.Profile{
+				"production": {
+					TermsURL:    "https://terms.example.com",
+					IdentityURL: "https://identity.example.com",
+				},
+				"local": {
+					TermsURL:   "http://localhost:8080",
+					CookieFile: "~/.local-cookies",
+					Auth:       config.AuthNoBrowser,
+				},
+				"ci": {
+					Auth:          config.AuthAgent,
+					AgentFile:     "/etc/terms/agent.json",
+					MacaroonsFile: "/etc/terms/macaroons.json",
+				},
+			},
+		},
+	}, {
+		about:   "empty file",
+		content: "",
+		config:  &config.Config{},
+	}, {
+		about:   "unknown field",
+		content: "profiles:\n  local:\n    url: http://localhost\n",
+		err:     `(?s)invalid configuration file ".*": .*field url not found.*`,
+	}, {
+		about:   "undefined default profile",
+		content: "default-profile: production\n",
+		err:     `invalid configuration file ".*": default profile "production" not defined`,
+	}, {
+		about:   "relative URL",
+		content: "profiles:\n  local:\n    terms-url: localhost:8080/terms\n",
+		err:     `invalid configuration file ".*": profile "local": invalid terms-url "localhost:8080/terms": not an absolute URL`,
+	}, {
+		about:   "invalid auth",
+		content: "profiles:\n  local:\n    auth: password\n",
+		err:     `invalid configuration file ".*": profile "local": auth "password" \(must be one of browser, no-browser, agent\) not valid`,
+	}}
+	for i, test := range tests {
+		c.Logf("running test %d: %s", i, test.about)
+		path := filepath.Join(c.MkDir(), "terms-client.yaml")
+		err := ioutil.WriteFile(path, []byte(test.content), 0600)
+		c.Assert(err, jc.ErrorIsNil)
+		cfg, err := config.Read(path)
+		if test.err != "" {
+			c.Assert(err, gc.ErrorMatches, test.err)
+			continue
+		}
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(cfg, jc.DeepEquals, test.config)
+	}
+}
+
+func (s *configSuite) TestReadMissingFile(c *gc.C) {
+	cfg, err := config.Read(filepath.Join(c.MkDir(), "missing.yaml"))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cfg, jc.DeepEquals, &config.Config{})
+}
+
+func (s *configSuite) TestProfile(c *gc.C) {
+	cfg := &config.Config{
+		Profiles: map[string]config.Profile{
+			"production": {TermsURL: "https://terms.example.com"},
+			"staging":    {TermsURL: "https://terms.staging.example.com"},
+		},
+	}
+	p, err := cfg.Profile("")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(p, jc.DeepEquals, config.Profile{})
+
+	p, err = cfg.Profile("staging")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(p.TermsURL, gc.Equals, "https://terms.staging.example.com")
+
+	cfg.DefaultProfile = "production"
+	p, err = cfg.Profile("")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(p.TermsURL, gc.Equals, "https://terms.example.com")
+
+	_, err = cfg.Profile("local")
+	c.Assert(err, gc.ErrorMatches, `profile "local" \(defined profiles: production, staging\) not found`)
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+
+	_, err = (&config.Config{}).Profile("local")
+	c.Assert(err, gc.ErrorMatches, `profile "local" \(no profiles defined\) not found`)
+}
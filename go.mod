@@ -13,9 +13,11 @@ require (
 	github.com/juju/persistent-cookiejar v0.0.0-20170428161559-d67418f14c93
 	github.com/juju/testing v0.0.0-20200923013621-75df6121fbb0
 	github.com/juju/utils v0.0.0-20200604140309-9d78121a29e0
+	github.com/pmezard/go-difflib v1.0.0
 	gopkg.in/check.v1 v1.0.0-20200902074654-038fdea0a05b
 	gopkg.in/juju/environschema.v1 v1.0.0
 	gopkg.in/macaroon-bakery.v2 v2.2.0
+	gopkg.in/macaroon.v2 v2.1.0
 	gopkg.in/yaml.v2 v2.3.0
 )
 
@@ -0,0 +1,119 @@
+// Copyright 2020 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+// Package idm provides a client for the parts of the identity manager
+// API used by the terms commands.
+package idm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/juju/errors"
+
+	"github.com/juju/terms-client/internal/httperror"
+)
+
+// requestIDHeader is the name of the header holding the request ID. The
+// ID is taken from the context value with the same name, as done by the
+// terms service client.
+const requestIDHeader = httperror.RequestIDHeader
+
+// HTTPClient defines the interface of the http client used to
+// send requests to the identity manager.
+type HTTPClient interface {
+	Do(*http.Request) (*http.Response, error)
+}
+
+// Client is a client of the identity manager.
+type Client struct {
+	url    string
+	client HTTPClient
+}
+
+// NewClient returns a new client of the identity manager
+// available at the given URL that uses the given http client.
+func NewClient(url string, client HTTPClient) *Client {
+	return &Client{
+		url:    strings.TrimSuffix(url, "/"),
+		client: client,
+	}
+}
+
+// WhoAmI returns the user's username as declared by the identity manager.
+func (c *Client) WhoAmI(ctx context.Context) (string, error) {
+	var username struct {
+		User string `json:"user"`
+	}
+	if err := c.get(ctx, "/whoami", &username); err != nil {
+		return "", errors.Annotate(err, "failed to get the current user")
+	}
+	return username.User, nil
+}
+
+// Groups returns the public groups the specified user belongs to.
+func (c *Client) Groups(ctx context.Context, username string) ([]string, error) {
+	var groups []string
+	if err := c.get(ctx, "/u/"+url.PathEscape(username)+"/groups", &groups); err != nil {
+		return nil, errors.Annotatef(err, "failed to get the groups of user %q", username)
+	}
+	return groups, nil
+}
+
+// get sends a GET request for the given path and unmarshals the JSON
+// response into v.
+func (c *Client) get(ctx context.Context, path string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.url+path, nil)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if id, ok := ctx.Value(requestIDHeader).(string); ok {
+		req.Header.Set(requestIDHeader, id)
+	}
+	response, err := c.client.Do(req)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer httperror.DiscardClose(response)
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if response.StatusCode != http.StatusOK {
+		return newError(req, response, data)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return errors.Annotatef(err, "cannot unmarshal response from %s", req.URL)
+	}
+	return nil
+}
+
+// Error holds an error returned by the identity manager.
+type Error = httperror.Error
+
+// ServiceError returns the identity manager error found in the chain
+// of errors wrapped by err and whether one was found.
+func ServiceError(err error) (*Error, bool) {
+	return httperror.Find(err)
+}
+
+// newError returns an error describing the unsuccessful response
+// with the given body. The returned error satisfies the juju/errors
+// predicate matching the status code of the response (e.g.
+// errors.IsUnauthorized for http.StatusUnauthorized).
+func newError(req *http.Request, response *http.Response, data []byte) error {
+	return httperror.New(req, response, data, errorMessage)
+}
+
+// errorMessage returns the message describing an unsuccessful
+// response whose body does not hold a JSON error. The body is not
+// used: the identity manager, or a proxy in front of it, may respond
+// with an HTML page.
+func errorMessage(response *http.Response, _ []byte) string {
+	return fmt.Sprintf("identity manager returned %q", response.Status)
+}
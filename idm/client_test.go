@@ -0,0 +1,153 @@
+// Copyright 2020 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package idm_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	stdtesting "testing"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/terms-client/idm"
+)
+
+func Test(t *stdtesting.T) {
+	gc.TestingT(t)
+}
+
+type clientSuite struct {
+	server   *httptest.Server
+	client   *idm.Client
+	status   int
+	body     string
+	header   http.Header
+	requests []*http.Request
+}
+
+var _ = gc.Suite(&clientSuite{})
+
+func (s *clientSuite) SetUpTest(c *gc.C) {
+	s.status = http.StatusOK
+	s.body = ""
+	s.header = make(http.Header)
+	s.requests = nil
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		s.requests = append(s.requests, req)
+		for k, v := range s.header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(s.status)
+		fmt.Fprint(w, s.body)
+	}))
+	s.client = idm.NewClient(s.server.URL+"/v1/", http.DefaultClient)
+}
+
+func (s *clientSuite) TearDownTest(c *gc.C) {
+	s.server.Close()
+}
+
+func (s *clientSuite) TestWhoAmI(c *gc.C) {
+	s.body = `{"user":"test-user"}`
+	user, err := s.client.WhoAmI(context.Background())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(user, gc.Equals, "test-user")
+	c.Assert(s.requests, gc.HasLen, 1)
+	c.Assert(s.requests[0].URL.Path, gc.Equals, "/v1/whoami")
+}
+
+func (s *clientSuite) TestGroups(c *gc.C) {
+	s.body = `["group1","group2"]`
+	groups, err := s.client.Groups(context.Background(), "test-user")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(groups, jc.DeepEquals, []string{"group1", "group2"})
+	c.Assert(s.requests, gc.HasLen, 1)
+	c.Assert(s.requests[0].URL.Path, gc.Equals, "/v1/u/test-user/groups")
+}
+
+func (s *clientSuite) TestErrors(c *gc.C) {
+	tests := []struct {
+		about     string
+		status    int
+		body      string
+		err       string
+		satisfies func(error) bool
+		code      string
+	}{{
+		about:     "unauthorized html page",
+		status:    http.StatusUnauthorized,
+		body:      "<html><body>Please log in</body></html>",
+		err:       `failed to get the groups of user "test-user": identity manager returned "401 Unauthorized"`,
+		satisfies: errors.IsUnauthorized,
+	}, {
+		about:     "forbidden",
+		status:    http.StatusForbidden,
+		body:      `{"code":"forbidden","message":"access denied"}`,
+		err:       `failed to get the groups of user "test-user": access denied`,
+		satisfies: errors.IsForbidden,
+		code:      "forbidden",
+	}, {
+		about:     "user not found",
+		status:    http.StatusNotFound,
+		body:      `{"code":"not found","message":"user test-user not found"}`,
+		err:       `failed to get the groups of user "test-user": user test-user not found`,
+		satisfies: errors.IsNotFound,
+		code:      "not found",
+	}, {
+		about:  "internal server error",
+		status: http.StatusInternalServerError,
+		body:   `{"error":"database is down"}`,
+		err:    `failed to get the groups of user "test-user": database is down`,
+	}, {
+		about:  "invalid response",
+		status: http.StatusOK,
+		body:   `<html></html>`,
+		err:    `failed to get the groups of user "test-user": cannot unmarshal response from .*: invalid character .*`,
+	}}
+	for i, test := range tests {
+		c.Logf("running test %d: %s", i, test.about)
+		s.status = test.status
+		s.body = test.body
+		_, err := s.client.Groups(context.Background(), "test-user")
+		c.Assert(err, gc.ErrorMatches, test.err)
+		if test.satisfies != nil {
+			c.Assert(test.satisfies(err), jc.IsTrue)
+		}
+		if test.status != http.StatusOK {
+			e, ok := idm.ServiceError(err)
+			c.Assert(ok, jc.IsTrue)
+			c.Assert(e.StatusCode, gc.Equals, test.status)
+			c.Assert(e.Code, gc.Equals, test.code)
+		}
+	}
+}
+
+func (s *clientSuite) TestRequestID(c *gc.C) {
+	s.status = http.StatusUnauthorized
+	ctx := context.WithValue(context.Background(), "X-Request-ID", "req-1")
+	_, err := s.client.WhoAmI(ctx)
+	c.Assert(err, gc.ErrorMatches, `failed to get the current user: .*`)
+	c.Assert(s.requests[0].Header.Get("X-Request-ID"), gc.Equals, "req-1")
+	e, ok := idm.ServiceError(err)
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(e.RequestID, gc.Equals, "req-1")
+
+	s.header.Set("X-Request-ID", "server-id")
+	_, err = s.client.WhoAmI(ctx)
+	e, ok = idm.ServiceError(err)
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(e.RequestID, gc.Equals, "server-id")
+}
+
+func (s *clientSuite) TestCanceled(c *gc.C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := s.client.WhoAmI(ctx)
+	c.Assert(errors.Cause(err), gc.ErrorMatches, `.*context canceled`)
+	c.Assert(s.requests, gc.HasLen, 0)
+}
@@ -0,0 +1,112 @@
+// Copyright 2020 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+// Package httperror holds the error type and helpers shared by the
+// HTTP clients of the terms service and of the identity manager.
+package httperror
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/juju/errors"
+)
+
+// RequestIDHeader is the name of the header holding the request ID.
+const RequestIDHeader = "X-Request-ID"
+
+// Error holds an error returned by a service.
+type Error struct {
+	// StatusCode holds the HTTP status code of the response.
+	StatusCode int
+
+	// Code holds the error code reported by the service, if any.
+	Code string
+
+	// Message holds the error message reported by the service.
+	Message string
+
+	// RequestID holds the ID of the request that failed, if known.
+	RequestID string
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Find returns the service error found in the chain of errors wrapped
+// by err and whether one was found.
+func Find(err error) (*Error, bool) {
+	for err != nil {
+		if e, ok := err.(*Error); ok {
+			return e, true
+		}
+		u, ok := err.(interface {
+			Underlying() error
+		})
+		if !ok {
+			return nil, false
+		}
+		err = u.Underlying()
+	}
+	return nil, false
+}
+
+// New returns an error describing the unsuccessful response with the
+// given body. The message is taken from the JSON error held by the body
+// or, when there is none, from the given fallback function. The
+// returned error satisfies the juju/errors predicate matching the
+// status code of the response (e.g. errors.IsNotFound for
+// http.StatusNotFound) and wraps an *Error that may be obtained by
+// calling Find.
+func New(req *http.Request, response *http.Response, data []byte, fallback func(response *http.Response, data []byte) string) error {
+	e := &Error{
+		StatusCode: response.StatusCode,
+		RequestID:  response.Header.Get(RequestIDHeader),
+	}
+	if e.RequestID == "" && req != nil {
+		e.RequestID = req.Header.Get(RequestIDHeader)
+	}
+	var body struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+		Code    string `json:"code"`
+	}
+	if err := json.Unmarshal(data, &body); err == nil {
+		e.Code = body.Code
+		e.Message = body.Error
+		if e.Message == "" {
+			e.Message = body.Message
+		}
+	}
+	if e.Message == "" {
+		e.Message = fallback(response, data)
+	}
+
+	switch response.StatusCode {
+	case http.StatusBadRequest:
+		return errors.NewBadRequest(e, "")
+	case http.StatusUnauthorized:
+		return errors.NewUnauthorized(e, "")
+	case http.StatusForbidden:
+		return errors.NewForbidden(e, "")
+	case http.StatusNotFound:
+		return errors.NewNotFound(e, "")
+	case http.StatusConflict:
+		return errors.NewAlreadyExists(e, "")
+	}
+	return e
+}
+
+// DiscardClose reads any remaining data from the response body and
+// closes it.
+func DiscardClose(response *http.Response) {
+	if response == nil || response.Body == nil {
+		return
+	}
+	io.Copy(ioutil.Discard, response.Body)
+	response.Body.Close()
+}
@@ -0,0 +1,108 @@
+// Copyright 2020 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package httperror_test
+
+import (
+	"fmt"
+	"net/http"
+	stdtesting "testing"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/terms-client/internal/httperror"
+)
+
+func Test(t *stdtesting.T) {
+	gc.TestingT(t)
+}
+
+type httperrorSuite struct{}
+
+var _ = gc.Suite(&httperrorSuite{})
+
+func (s *httperrorSuite) TestNew(c *gc.C) {
+	fallback := func(response *http.Response, data []byte) string {
+		return "fallback " + response.Status
+	}
+	tests := []struct {
+		about     string
+		status    int
+		header    string
+		body      string
+		expect    httperror.Error
+		satisfies func(error) bool
+	}{{
+		about:  "error field",
+		status: http.StatusBadRequest,
+		body:   `{"error":"bad term","code":"bad request"}`,
+		expect: httperror.Error{
+			StatusCode: http.StatusBadRequest,
+			Code:       "bad request",
+			Message:    "bad term",
+			RequestID:  "req-1",
+		},
+		satisfies: errors.IsBadRequest,
+	}, {
+		about:  "message field",
+		status: http.StatusNotFound,
+		header: "server-id",
+		body:   `{"message":"term not found"}`,
+		expect: httperror.Error{
+			StatusCode: http.StatusNotFound,
+			Message:    "term not found",
+			RequestID:  "server-id",
+		},
+		satisfies: errors.IsNotFound,
+	}, {
+		about:  "no JSON error",
+		status: http.StatusConflict,
+		body:   "<html></html>",
+		expect: httperror.Error{
+			StatusCode: http.StatusConflict,
+			Message:    "fallback 409 Conflict",
+			RequestID:  "req-1",
+		},
+		satisfies: errors.IsAlreadyExists,
+	}, {
+		about:  "unmapped status",
+		status: http.StatusInternalServerError,
+		body:   `{"error":"database is down"}`,
+		expect: httperror.Error{
+			StatusCode: http.StatusInternalServerError,
+			Message:    "database is down",
+			RequestID:  "req-1",
+		},
+	}}
+	for i, test := range tests {
+		c.Logf("running test %d: %s", i, test.about)
+		req, err := http.NewRequest("GET", "http://example.com", nil)
+		c.Assert(err, jc.ErrorIsNil)
+		req.Header.Set(httperror.RequestIDHeader, "req-1")
+		response := &http.Response{
+			StatusCode: test.status,
+			Status:     fmt.Sprintf("%d %s", test.status, http.StatusText(test.status)),
+			Header:     make(http.Header),
+		}
+		if test.header != "" {
+			response.Header.Set(httperror.RequestIDHeader, test.header)
+		}
+		err = httperror.New(req, response, []byte(test.body), fallback)
+		c.Assert(err, gc.ErrorMatches, test.expect.Message)
+		if test.satisfies != nil {
+			c.Assert(test.satisfies(err), jc.IsTrue)
+		}
+		e, ok := httperror.Find(errors.Annotate(err, "context"))
+		c.Assert(ok, jc.IsTrue)
+		c.Assert(*e, jc.DeepEquals, test.expect)
+	}
+}
+
+func (s *httperrorSuite) TestFindNotFound(c *gc.C) {
+	_, ok := httperror.Find(errors.New("other error"))
+	c.Assert(ok, jc.IsFalse)
+	_, ok = httperror.Find(nil)
+	c.Assert(ok, jc.IsFalse)
+}
@@ -0,0 +1,259 @@
+// Copyright 2020 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+// Package lint checks the content of Terms and Conditions documents
+// for common mistakes before they are pushed to the terms service.
+package lint
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/juju/errors"
+)
+
+// Severity holds the severity of a finding.
+type Severity string
+
+const (
+	// Error findings make the document unfit to be pushed.
+	Error Severity = "error"
+
+	// Warning findings point at likely mistakes.
+	Warning Severity = "warning"
+)
+
+// Names of the rules checked by Lint.
+const (
+	RuleEncoding    = "encoding"
+	RuleSize        = "size"
+	RuleWhitespace  = "trailing-whitespace"
+	RuleTitle       = "title"
+	RuleLink        = "link"
+	RulePlaceholder = "placeholder"
+)
+
+// DefaultMaxSize holds the default maximum size of a document in bytes.
+const DefaultMaxSize = 64 * 1024
+
+// Finding describes a problem found in a document.
+type Finding struct {
+	// Line holds the 1-based line number of the problem, or 0
+	// if the problem concerns the whole document.
+	Line int `json:"line,omitempty" yaml:"line,omitempty"`
+
+	// Rule holds the name of the rule that found the problem.
+	Rule string `json:"rule" yaml:"rule"`
+
+	// Severity holds the severity of the problem.
+	Severity Severity `json:"severity" yaml:"severity"`
+
+	// Message describes the problem.
+	Message string `json:"message" yaml:"message"`
+}
+
+// String implements fmt.Stringer.
+func (f Finding) String() string {
+	if f.Line == 0 {
+		return fmt.Sprintf("%s: %s (%s)", f.Severity, f.Message, f.Rule)
+	}
+	return fmt.Sprintf("%d: %s: %s (%s)", f.Line, f.Severity, f.Message, f.Rule)
+}
+
+// Options holds the parameters of the checks.
+type Options struct {
+	// MaxSize holds the maximum size of a document in bytes. If
+	// it is zero, DefaultMaxSize is used.
+	MaxSize int
+}
+
+var (
+	placeholderRE = regexp.MustCompile(`\b(TODO|FIXME|XXX|TBD)\b|(?i:lorem ipsum)`)
+	inlineLinkRE  = regexp.MustCompile(`\[([^\]]*)\]\(\s*([^)\s]*)(?:\s+"[^"]*")?\s*(\)?)`)
+	refLinkRE     = regexp.MustCompile(`\[([^\]]+)\]\[([^\]]*)\]`)
+	linkDefRE     = regexp.MustCompile(`^ {0,3}\[([^\]]+)\]:\s*(\S*)`)
+	setextRE      = regexp.MustCompile(`^(=+|-+)\s*$`)
+)
+
+// Lint checks the content of a document and returns the problems
+// found, ordered by line.
+func Lint(content []byte, opts Options) []Finding {
+	maxSize := opts.MaxSize
+	if maxSize == 0 {
+		maxSize = DefaultMaxSize
+	}
+	var findings []Finding
+	if len(content) == 0 {
+		return []Finding{{
+			Rule:     RuleSize,
+			Severity: Error,
+			Message:  "empty document",
+		}}
+	}
+	if len(content) > maxSize {
+		findings = append(findings, Finding{
+			Rule:     RuleSize,
+			Severity: Error,
+			Message:  fmt.Sprintf("document is %d bytes, exceeding the limit of %d bytes", len(content), maxSize),
+		})
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	for i, line := range lines {
+		// The CR of a CRLF line ending is part of the line ending,
+		// not of the content, so it is not reported as trailing
+		// whitespace.
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	refs := linkDefinitions(lines)
+	findings = append(findings, checkTitle(lines)...)
+	for i, line := range lines {
+		n := i + 1
+		if !utf8.ValidString(line) {
+			findings = append(findings, Finding{
+				Line:     n,
+				Rule:     RuleEncoding,
+				Severity: Error,
+				Message:  "invalid UTF-8",
+			})
+		}
+		if trimmed := strings.TrimRight(line, " \t\r"); trimmed != line {
+			findings = append(findings, Finding{
+				Line:     n,
+				Rule:     RuleWhitespace,
+				Severity: Warning,
+				Message:  "trailing whitespace",
+			})
+		}
+		for _, m := range placeholderRE.FindAllString(line, -1) {
+			findings = append(findings, Finding{
+				Line:     n,
+				Rule:     RulePlaceholder,
+				Severity: Warning,
+				Message:  fmt.Sprintf("placeholder text %q", m),
+			})
+		}
+		findings = append(findings, checkLinks(n, line, refs)...)
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Line < findings[j].Line
+	})
+	return findings
+}
+
+// checkTitle checks that the document starts with a title, either an
+// ATX heading ("# Title") or a setext heading (a line underlined
+// with "=" or "-").
+func checkTitle(lines []string) []Finding {
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") && strings.TrimSpace(strings.TrimLeft(line, "#")) != "" {
+			return nil
+		}
+		if i+1 < len(lines) && setextRE.MatchString(lines[i+1]) {
+			return nil
+		}
+		return []Finding{{
+			Line:     i + 1,
+			Rule:     RuleTitle,
+			Severity: Warning,
+			Message:  "document does not start with a title",
+		}}
+	}
+	return []Finding{{
+		Rule:     RuleTitle,
+		Severity: Error,
+		Message:  "document has no content",
+	}}
+}
+
+// linkDefinitions returns the set of the reference link labels
+// defined in the document.
+func linkDefinitions(lines []string) map[string]bool {
+	refs := make(map[string]bool)
+	for _, line := range lines {
+		if m := linkDefRE.FindStringSubmatch(line); m != nil {
+			refs[strings.ToLower(m[1])] = true
+		}
+	}
+	return refs
+}
+
+// checkLinks checks the Markdown links in the given line.
+func checkLinks(n int, line string, refs map[string]bool) []Finding {
+	var findings []Finding
+	broken := func(format string, args ...interface{}) {
+		findings = append(findings, Finding{
+			Line:     n,
+			Rule:     RuleLink,
+			Severity: Error,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+	if m := linkDefRE.FindStringSubmatch(line); m != nil {
+		if m[2] == "" {
+			broken("link definition %q has no target", m[1])
+		} else if err := checkURL(m[2]); err != nil {
+			broken("link definition %q: %v", m[1], err)
+		}
+		return findings
+	}
+	for _, m := range inlineLinkRE.FindAllStringSubmatch(line, -1) {
+		target := m[2]
+		switch {
+		case m[3] == "":
+			broken("link %q is not closed", m[1])
+		case target == "":
+			broken("link %q has no target", m[1])
+		default:
+			if err := checkURL(target); err != nil {
+				broken("link %q: %v", m[1], err)
+			}
+		}
+	}
+	for _, m := range refLinkRE.FindAllStringSubmatch(line, -1) {
+		label := m[2]
+		if label == "" {
+			label = m[1]
+		}
+		if !refs[strings.ToLower(label)] {
+			broken("link %q refers to undefined reference %q", m[1], label)
+		}
+	}
+	return findings
+}
+
+// checkURL checks that the link target is a valid URL.
+func checkURL(target string) error {
+	target = strings.Trim(target, "<>")
+	u, err := url.Parse(target)
+	if err != nil {
+		return errors.Errorf("invalid URL %q", target)
+	}
+	switch u.Scheme {
+	case "http", "https":
+		if u.Host == "" {
+			return errors.Errorf("URL %q has no host", target)
+		}
+	case "mailto":
+		if u.Opaque == "" {
+			return errors.Errorf("URL %q has no address", target)
+		}
+	case "":
+		// Anchors refer to the document itself, but relative links
+		// cannot be resolved once the document is published.
+		if !strings.HasPrefix(target, "#") {
+			return errors.Errorf("relative URL %q", target)
+		}
+	default:
+		return errors.Errorf("unsupported URL scheme %q", u.Scheme)
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+// Copyright 2020 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package lint_test
+
+import (
+	"strings"
+	stdtesting "testing"
+
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/terms-client/lint"
+)
+
+func Test(t *stdtesting.T) {
+	gc.TestingT(t)
+}
+
+type lintSuite struct{}
+
+var _ = gc.Suite(&lintSuite{})
+
+func (s *lintSuite) TestLint(c *gc.C) {
+	tests := []struct {
+		about    string
+		content  string
+		maxSize  int
+		findings []lint.Finding
+	}{{
+		about: "clean document",
+		content: `# Enterprise plan
+
+See [the website](https://example.com "website") or [mail us](mailto:terms@example.com).
+Read the [details][1] and the [summary](#summary).
+
+[1]: https://example.com/details
+`,
+	}, {
+		about:   "CRLF line endings",
+		content: "# Enterprise plan\r\nTerms. \r\nConditions.\r\n",
+		findings: []lint.Finding{{
+			Line:     2,
+			Rule:     lint.RuleWhitespace,
+			Severity: lint.Warning,
+			Message:  "trailing whitespace",
+		}},
+	}, {
+		about: "setext title",
+		content: `Enterprise plan
+===============
+Terms.
+`,
+	}, {
+		about:   "empty document",
+		content: "",
+		findings: []lint.Finding{{
+			Rule:     lint.RuleSize,
+			Severity: lint.Error,
+			Message:  "empty document",
+		}},
+	}, {
+		about:   "blank document",
+		content: "\n  \n",
+		findings: []lint.Finding{{
+			Rule:     lint.RuleTitle,
+			Severity: lint.Error,
+			Message:  "document has no content",
+		}, {
+			Line:     2,
+			Rule:     lint.RuleWhitespace,
+			Severity: lint.Warning,
+			Message:  "trailing whitespace",
+		}},
+	}, {
+		about:   "too large",
+		content: "# Title\n" + strings.Repeat("a", 20) + "\n",
+		maxSize: 16,
+		findings: []lint.Finding{{
+			Rule:     lint.RuleSize,
+			Severity: lint.Error,
+			Message:  "document is 29 bytes, exceeding the limit of 16 bytes",
+		}},
+	}, {
+		about:   "missing title, trailing whitespace and placeholders",
+		content: "\nTerms and conditions \nTODO: fill in. XXX\nlorem ipsum, TODOS\n",
+		findings: []lint.Finding{{
+			Line:     2,
+			Rule:     lint.RuleTitle,
+			Severity: lint.Warning,
+			Message:  "document does not start with a title",
+		}, {
+			Line:     2,
+			Rule:     lint.RuleWhitespace,
+			Severity: lint.Warning,
+			Message:  "trailing whitespace",
+		}, {
+			Line:     3,
+			Rule:     lint.RulePlaceholder,
+			Severity: lint.Warning,
+			Message:  `placeholder text "TODO"`,
+		}, {
+			Line:     3,
+			Rule:     lint.RulePlaceholder,
+			Severity: lint.Warning,
+			Message:  `placeholder text "XXX"`,
+		}, {
+			Line:     4,
+			Rule:     lint.RulePlaceholder,
+			Severity: lint.Warning,
+			Message:  `placeholder text "lorem ipsum"`,
+		}},
+	}, {
+		about:   "invalid UTF-8",
+		content: "# Title\nbad \xff byte\n",
+		findings: []lint.Finding{{
+			Line:     2,
+			Rule:     lint.RuleEncoding,
+			Severity: lint.Error,
+			Message:  "invalid UTF-8",
+		}},
+	}, {
+		about: "broken links",
+		content: `# Title
+[empty]() [relative](terms.md) [ftp](ftp://example.com/x) [nohost](https:///path)
+[open](https://example.com [undefined][ref] [mail](mailto:)
+[def]:
+`,
+		findings: []lint.Finding{{
+			Line:     2,
+			Rule:     lint.RuleLink,
+			Severity: lint.Error,
+			Message:  `link "empty" has no target`,
+		}, {
+			Line:     2,
+			Rule:     lint.RuleLink,
+			Severity: lint.Error,
+			Message:  `link "relative": relative URL "terms.md"`,
+		}, {
+			Line:     2,
+			Rule:     lint.RuleLink,
+			Severity: lint.Error,
+			Message:  `link "ftp": unsupported URL scheme "ftp"`,
+		}, {
+			Line:     2,
+			Rule:     lint.RuleLink,
+			Severity: lint.Error,
+			Message:  `link "nohost": URL "https:///path" has no host`,
+		}, {
+			Line:     3,
+			Rule:     lint.RuleLink,
+			Severity: lint.Error,
+			Message:  `link "open" is not closed`,
+		}, {
+			Line:     3,
+			Rule:     lint.RuleLink,
+			Severity: lint.Error,
+			Message:  `link "mail": URL "mailto:" has no address`,
+		}, {
+			Line:     3,
+			Rule:     lint.RuleLink,
+			Severity: lint.Error,
+			Message:  `link "undefined" refers to undefined reference "ref"`,
+		}, {
+			Line:     4,
+			Rule:     lint.RuleLink,
+			Severity: lint.Error,
+			Message:  `link definition "def" has no target`,
+		}},
+	}}
+	for i, test := range tests {
+		c.Logf("running test %d: %s", i, test.about)
+		findings := lint.Lint([]byte(test.content), lint.Options{MaxSize: test.maxSize})
+		c.Assert(findings, jc.DeepEquals, test.findings)
+	}
+}
+
+func (s *lintSuite) TestFindingString(c *gc.C) {
+	c.Assert(lint.Finding{
+		Line:     3,
+		Rule:     lint.RuleWhitespace,
+		Severity: lint.Warning,
+		Message:  "trailing whitespace",
+	}.String(), gc.Equals, "3: warning: trailing whitespace (trailing-whitespace)")
+	c.Assert(lint.Finding{
+		Rule:     lint.RuleSize,
+		Severity: lint.Error,
+		Message:  "empty document",
+	}.String(), gc.Equals, "error: empty document (size)")
+}